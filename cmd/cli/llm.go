@@ -0,0 +1,386 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	kbxMod "github.com/kubex-ecosystem/kbx/internal/module/kbx"
+	registry "github.com/kubex-ecosystem/kbx/tools/providers"
+	kbxTypes "github.com/kubex-ecosystem/kbx/types"
+
+	gl "github.com/kubex-ecosystem/logz"
+	"github.com/spf13/cobra"
+)
+
+// NewLLMCommand creates the llm command
+func NewLLMCommand() *cobra.Command {
+	var (
+		configPath string
+		debug      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "llm",
+		Short: "Inspect LLM providers and chat with them",
+		Long: `Inspect the providers configured in the LLM registry and chat with them.
+
+Examples:
+  kbx llm providers
+  kbx llm models groq
+  kbx llm chat -p openai "Explain SSE in one paragraph"
+  git diff | kbx llm chat -p anthropic "Review this diff"
+  kbx llm chat -p gemini`,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			gl.SetDebugMode(debug)
+			// Flags parsed fine; runtime failures should not print usage.
+			cmd.SilenceUsage = true
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&configPath, "config", "c",
+		getEnvOrDefault("KUBEX_LLM_PROVIDER_CONFIG_PATH", kbxTypes.NewLLMConfigDefault().FilePath),
+		"Path to the LLM provider configuration file")
+	cmd.PersistentFlags().BoolVarP(&debug, "debug", "D", false, "Enable debug logging")
+
+	load := func() (*registry.Registry, error) {
+		return registry.Load(configPath)
+	}
+
+	cmd.AddCommand(llmProvidersCommand(load))
+	cmd.AddCommand(llmModelsCommand(load))
+	cmd.AddCommand(llmChatCommand(load))
+
+	return cmd
+}
+
+type registryLoader func() (*registry.Registry, error)
+
+type providerStatus struct {
+	Name         string   `json:"name"`
+	Type         string   `json:"type"`
+	Status       string   `json:"status"`
+	Error        string   `json:"error,omitempty"`
+	KeySources   []string `json:"key_sources"`
+	DefaultModel string   `json:"default_model,omitempty"`
+}
+
+func llmProvidersCommand(load registryLoader) *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "providers",
+		Short: "List configured providers with status, key source and default model",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			reg, err := load()
+			if err != nil {
+				return err
+			}
+
+			cfg := reg.Config()
+			names := make([]string, 0, len(cfg.Providers))
+			for name := range cfg.Providers {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+
+			statuses := make([]providerStatus, 0, len(names))
+			for _, name := range names {
+				pc := cfg.Providers[name]
+				st := providerStatus{
+					Name:         name,
+					Type:         pc.Type(),
+					Status:       "ready",
+					KeySources:   reg.KeySources(name),
+					DefaultModel: pc.DefaultModel,
+				}
+				if p := reg.ResolveProvider(name); p == nil {
+					st.Status = "not loaded"
+				} else if err := p.Available(); err != nil {
+					st.Status = "unavailable"
+					st.Error = err.Error()
+				}
+				statuses = append(statuses, st)
+			}
+
+			if asJSON {
+				return writeJSON(cmd.OutOrStdout(), statuses)
+			}
+			tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "NAME\tTYPE\tSTATUS\tKEY SOURCE\tDEFAULT MODEL")
+			for _, st := range statuses {
+				keys := strings.Join(st.KeySources, ",")
+				if keys == "" {
+					keys = "-"
+				}
+				model := st.DefaultModel
+				if model == "" {
+					model = "-"
+				}
+				status := st.Status
+				if st.Error != "" {
+					status += " (" + st.Error + ")"
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", st.Name, st.Type, status, keys, model)
+			}
+			return tw.Flush()
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print providers as JSON")
+	return cmd
+}
+
+func llmModelsCommand(load registryLoader) *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "models <provider>",
+		Short: "List the models offered by a provider",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			reg, err := load()
+			if err != nil {
+				return err
+			}
+			p := reg.ResolveProvider(args[0])
+			if p == nil {
+				return gl.Errorf("provider '%s' is not loaded (see 'kbx llm providers')", args[0])
+			}
+
+			models, err := p.ListModels(cmd.Context())
+			if err != nil {
+				return gl.Errorf("failed to list models for '%s': %v", args[0], err)
+			}
+			slices.Sort(models)
+
+			if asJSON {
+				return writeJSON(cmd.OutOrStdout(), models)
+			}
+			for _, m := range models {
+				fmt.Fprintln(cmd.OutOrStdout(), m)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print models as JSON")
+	return cmd
+}
+
+type chatOptions struct {
+	provider    string
+	model       string
+	system      string
+	temperature float32
+	asJSON      bool
+	noUsage     bool
+
+	reasoningEffort string
+	showReasoning   bool
+}
+
+func llmChatCommand(load registryLoader) *cobra.Command {
+	opts := chatOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "chat [prompt]",
+		Short: "Chat with a provider (one-shot when a prompt or stdin is given, REPL otherwise)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			reg, err := load()
+			if err != nil {
+				return err
+			}
+			if opts.provider == "" {
+				opts.provider = pickProvider(reg)
+			}
+			if reg.ResolveProvider(opts.provider) == nil {
+				return gl.Errorf("provider '%s' is not loaded (see 'kbx llm providers')", opts.provider)
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			prompt := strings.Join(args, " ")
+			if stdinPiped() {
+				data, err := io.ReadAll(cmd.InOrStdin())
+				if err != nil {
+					return gl.Errorf("failed to read stdin: %v", err)
+				}
+				if input := strings.TrimSpace(string(data)); input != "" {
+					prompt = strings.TrimSpace(prompt + "\n\n" + input)
+				}
+				if prompt == "" {
+					return gl.Errorf("empty prompt")
+				}
+			}
+
+			var history []kbxTypes.Message
+			if opts.system != "" {
+				history = append(history, kbxTypes.Message{Role: "system", Content: opts.system})
+			}
+
+			if prompt != "" {
+				history = append(history, kbxTypes.Message{Role: "user", Content: prompt})
+				_, err := streamChat(ctx, cmd, reg, opts, history)
+				return err
+			}
+			return chatREPL(ctx, cmd, reg, opts, history)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.provider, "provider", "p", "", "Provider name (defaults to "+kbxMod.DefaultLLMProvider+" or the only loaded provider)")
+	cmd.Flags().StringVarP(&opts.model, "model", "m", "", "Model (defaults to the provider's default model)")
+	cmd.Flags().StringVarP(&opts.system, "system", "s", "", "System prompt")
+	cmd.Flags().Float32VarP(&opts.temperature, "temperature", "t", kbxMod.DefaultLLMTemperature, "Sampling temperature")
+	cmd.Flags().BoolVar(&opts.asJSON, "json", false, "Print raw chunks as JSON lines")
+	cmd.Flags().BoolVar(&opts.noUsage, "no-usage", false, "Do not print the usage summary")
+	cmd.Flags().StringVar(&opts.reasoningEffort, "reasoning-effort", "", "Reasoning effort for thinking models (low, medium, high)")
+	cmd.Flags().BoolVar(&opts.showReasoning, "show-reasoning", false, "Print reasoning to stderr as it streams")
+	return cmd
+}
+
+func chatREPL(ctx context.Context, cmd *cobra.Command, reg *registry.Registry, opts chatOptions, history []kbxTypes.Message) error {
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(cmd.ErrOrStderr(), "Chatting with %s. Type /reset to clear the conversation, /exit to quit.\n", opts.provider)
+
+	scanner := bufio.NewScanner(cmd.InOrStdin())
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	base := len(history)
+	for {
+		fmt.Fprint(out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		switch line {
+		case "":
+			continue
+		case "/exit", "/quit":
+			return nil
+		case "/reset":
+			history = history[:base]
+			continue
+		}
+
+		history = append(history, kbxTypes.Message{Role: "user", Content: line})
+		reply, err := streamChat(ctx, cmd, reg, opts, history)
+		if err != nil {
+			// Keep the session alive; drop the turn that failed.
+			history = history[:len(history)-1]
+			fmt.Fprintln(cmd.ErrOrStderr(), "error:", err)
+			if ctx.Err() != nil {
+				return nil
+			}
+			continue
+		}
+		history = append(history, kbxTypes.Message{Role: "assistant", Content: reply})
+	}
+}
+
+// streamChat sends one request, prints the stream as it arrives and returns the full reply.
+func streamChat(ctx context.Context, cmd *cobra.Command, reg *registry.Registry, opts chatOptions, messages []kbxTypes.Message) (string, error) {
+	out := cmd.OutOrStdout()
+	stream, err := reg.Chat(ctx, kbxTypes.ChatRequest{
+		Provider: opts.provider,
+		Model:    opts.model,
+		Messages: messages,
+		Temp:     opts.temperature,
+		Stream:   true,
+
+		ReasoningEffort: opts.reasoningEffort,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		reply    strings.Builder
+		usage    *kbxTypes.Usage
+		last     string
+		thinking bool
+	)
+	enc := json.NewEncoder(out)
+	for chunk := range stream {
+		if opts.asJSON {
+			if err := enc.Encode(chunk); err != nil {
+				return "", err
+			}
+		} else {
+			if chunk.HasReasoning() && opts.showReasoning {
+				fmt.Fprint(cmd.ErrOrStderr(), chunk.Reasoning)
+				thinking = true
+			}
+			if chunk.HasContent() {
+				// Close the reasoning block before the answer starts.
+				if thinking {
+					fmt.Fprintln(cmd.ErrOrStderr())
+					thinking = false
+				}
+				fmt.Fprint(out, chunk.Content)
+			}
+		}
+		reply.WriteString(chunk.Content)
+		if chunk.HasToolCall() && !opts.asJSON {
+			args, _ := json.Marshal(chunk.ToolCall.Args)
+			fmt.Fprintf(out, "\n[tool call] %s(%s)\n", chunk.ToolCall.Name, args)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		if chunk.IsError() {
+			last = chunk.Error
+		}
+	}
+	if !opts.asJSON && reply.Len() > 0 && !strings.HasSuffix(reply.String(), "\n") {
+		fmt.Fprintln(out)
+	}
+
+	if usage != nil && !opts.noUsage && !opts.asJSON {
+		reasoning := ""
+		if usage.Reasoning > 0 {
+			reasoning = fmt.Sprintf(", reasoning %d", usage.Reasoning)
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "[%s/%s] %d tokens (prompt %d, completion %d%s), $%.6f, %dms\n",
+			usage.Provider, usage.Model, usage.Tokens, usage.Prompt, usage.Completion, reasoning, usage.CostUSD, usage.Ms)
+	}
+	if last != "" {
+		return reply.String(), gl.Errorf("%s", last)
+	}
+	if ctx.Err() != nil {
+		return reply.String(), ctx.Err()
+	}
+	return reply.String(), nil
+}
+
+// pickProvider returns the default provider when loaded, else the first loaded one.
+func pickProvider(reg *registry.Registry) string {
+	loaded := reg.ListProviders()
+	if slices.Contains(loaded, kbxMod.DefaultLLMProvider) || len(loaded) == 0 {
+		return kbxMod.DefaultLLMProvider
+	}
+	return loaded[0]
+}
+
+func stdinPiped() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice == 0
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -2,6 +2,7 @@
 package module
 
 import (
+	"github.com/kubex-ecosystem/kbx/cmd/cli"
 	"github.com/kubex-ecosystem/kbx/internal/module/version"
 
 	kbxInfo "github.com/kubex-ecosystem/kbx/tools/info"
@@ -72,6 +73,7 @@ func (m *Kbx) Command() *cobra.Command {
 	}
 
 	cmd.AddCommand(version.CliCommand())
+	cmd.AddCommand(cli.NewLLMCommand())
 
 	kbxStyle.SetUsageTemplate(cmd)
 
@@ -0,0 +1,197 @@
+package mailing
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubex-ecosystem/kbx/mailing/templates"
+	"github.com/kubex-ecosystem/kbx/types"
+
+	gl "github.com/kubex-ecosystem/logz"
+)
+
+// EmailNotifierName é o nome (e o NotifierEvent.Type) padrão do canal de e-mail.
+const EmailNotifierName = "email"
+
+// EmailNotifier implementa types.NotifierProvider enviando eventos por e-mail via Mailer.
+type EmailNotifier struct {
+	Mailer *Mailer
+	// ChannelName sobrescreve o nome do canal (EmailNotifierName por padrão).
+	ChannelName string
+	From        string
+	FromName    string
+	// To recebe os eventos sem Recipient.
+	To []string
+	// Loader, quando definido, renderiza o corpo HTML com um template escolhido pela prioridade.
+	Loader templates.TemplateLoader
+	// Templates mapeia prioridade -> template; a chave "default" cobre as demais.
+	// Sem entrada, tenta "notification-<prioridade>" e depois "notification".
+	Templates map[string]string
+}
+
+// NotificationData é o dado entregue aos templates de notificação.
+type NotificationData struct {
+	Type      string
+	Recipient string
+	Subject   string
+	Content   string
+	Priority  string
+	Metadata  map[string]any
+	CreatedAt time.Time
+}
+
+// NewEmailNotifier cria um EmailNotifier; loader pode ser nil (corpo apenas em texto).
+func NewEmailNotifier(m *Mailer, from string, loader templates.TemplateLoader) *EmailNotifier {
+	return &EmailNotifier{Mailer: m, From: from, Loader: loader}
+}
+
+func (n *EmailNotifier) Name() string {
+	if n.ChannelName != "" {
+		return n.ChannelName
+	}
+	return EmailNotifierName
+}
+
+// Notify monta o e-mail do evento e o envia pelo Mailer (com o retry dele).
+func (n *EmailNotifier) Notify(ctx context.Context, event types.NotifierEvent[any]) error {
+	if n.Mailer == nil {
+		return gl.Errorf("email notifier has no mailer")
+	}
+	req, err := n.Message(ctx, event)
+	if err != nil {
+		return err
+	}
+	if err := n.Mailer.Send(ctx, req); err != nil {
+		return gl.Errorf("failed to send '%s' notification to %s: %v", req.Subject, strings.Join(req.To, ", "), err)
+	}
+	return nil
+}
+
+// Message converte o evento em MailRequest sem enviá-lo. O Recipient pode
+// listar vários endereços separados por vírgula.
+func (n *EmailNotifier) Message(ctx context.Context, event types.NotifierEvent[any]) (*MailRequest, error) {
+	data := NotificationData{
+		Type:      event.Type(ctx),
+		Recipient: event.Recipient(ctx),
+		Subject:   event.Subject(ctx),
+		Content:   event.Content(ctx),
+		Priority:  strings.ToLower(strings.TrimSpace(event.Priority(ctx))),
+		Metadata:  event.Metadata(ctx),
+		CreatedAt: event.CreatedAt(ctx),
+	}
+
+	to := splitAddresses(data.Recipient)
+	if len(to) == 0 {
+		to = n.To
+	}
+	if len(to) == 0 {
+		return nil, gl.Errorf("notification '%s' has no email recipient", data.Subject)
+	}
+
+	// Quebras de linha no assunto viram espaço: ele vai direto para o cabeçalho Subject.
+	subject := strings.Join(strings.FieldsFunc(data.Subject, func(r rune) bool { return r == '\r' || r == '\n' }), " ")
+	if subject == "" {
+		subject = "Notification"
+	}
+	if data.Priority == "high" || data.Priority == "critical" {
+		subject = "[" + strings.ToUpper(data.Priority) + "] " + subject
+	}
+
+	req := &MailRequest{
+		Name:    n.FromName,
+		From:    n.From,
+		To:      to,
+		Subject: subject,
+		Text:    notificationText(data),
+	}
+	body, err := n.renderHTML(data)
+	if err != nil {
+		return nil, err
+	}
+	req.HTML = body
+	return req, nil
+}
+
+// renderHTML renderiza o template da prioridade do evento. Sem loader, ou sem
+// template encontrado, gera um HTML simples com o conteúdo e os metadados.
+func (n *EmailNotifier) renderHTML(data NotificationData) (string, error) {
+	if n.Loader != nil {
+		for _, name := range n.templateCandidates(data.Priority) {
+			tmpl, err := n.Loader.LoadHTML(name)
+			if err != nil {
+				continue
+			}
+			body, err := RenderHTML(tmpl, data)
+			if err != nil {
+				return "", gl.Errorf("failed to render notification template '%s': %v", name, err)
+			}
+			return body, nil
+		}
+		gl.Debugf("No notification template found for priority '%s', using the plain layout", data.Priority)
+	}
+
+	var b strings.Builder
+	b.WriteString("<p>" + strings.ReplaceAll(html.EscapeString(data.Content), "\n", "<br>") + "</p>")
+	if len(data.Metadata) > 0 {
+		b.WriteString("<table>")
+		for _, k := range sortedKeys(data.Metadata) {
+			fmt.Fprintf(&b, "<tr><th align=\"left\">%s</th><td>%s</td></tr>", html.EscapeString(k), html.EscapeString(fmt.Sprint(data.Metadata[k])))
+		}
+		b.WriteString("</table>")
+	}
+	return b.String(), nil
+}
+
+// templateCandidates lista, em ordem, os templates tentados para a prioridade.
+func (n *EmailNotifier) templateCandidates(priority string) []string {
+	if len(n.Templates) > 0 {
+		names := []string{}
+		if name, ok := n.Templates[priority]; ok {
+			names = append(names, name)
+		}
+		if name, ok := n.Templates["default"]; ok {
+			names = append(names, name)
+		}
+		return names
+	}
+	if priority == "" {
+		return []string{"notification"}
+	}
+	return []string{"notification-" + priority, "notification"}
+}
+
+// notificationText é o corpo em texto puro: conteúdo seguido dos metadados.
+func notificationText(data NotificationData) string {
+	var b strings.Builder
+	b.WriteString(data.Content)
+	if len(data.Metadata) > 0 {
+		b.WriteString("\n\n")
+		for _, k := range sortedKeys(data.Metadata) {
+			fmt.Fprintf(&b, "%s: %v\n", k, data.Metadata[k])
+		}
+	}
+	return b.String()
+}
+
+func splitAddresses(s string) []string {
+	var out []string
+	for _, addr := range strings.Split(s, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			out = append(out, addr)
+		}
+	}
+	return out
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io"
+	"mime"
 	"mime/quotedprintable"
 	"net/smtp"
 	"strings"
@@ -42,13 +43,20 @@ func sendSMTPMessage(c *smtp.Client, msg *types.Email) error {
 	return err
 }
 
+// encodeHeader strips CR/LF, so a value cannot inject extra headers, and
+// applies RFC 2047 encoding when it is not plain ASCII.
+func encodeHeader(value string) string {
+	value = strings.Join(strings.FieldsFunc(value, func(r rune) bool { return r == '\r' || r == '\n' }), " ")
+	return mime.QEncoding.Encode("utf-8", value)
+}
+
 // writeRFC822 – builds a *very clean* RFC message
 func writeRFC822(w io.Writer, msg *types.Email) {
 	boundary := "KBXMAIL-" + "BOUNDARY"
 
 	fmt.Fprintf(w, "From: %s\r\n", msg.From)
 	fmt.Fprintf(w, "To: %s\r\n", joinAddressList(msg.To))
-	fmt.Fprintf(w, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(w, "Subject: %s\r\n", encodeHeader(msg.Subject))
 	fmt.Fprintf(w, "MIME-Version: 1.0\r\n")
 	fmt.Fprintf(w, "Content-Type: multipart/mixed; boundary=\"%s\"\r\n\r\n", boundary)
 
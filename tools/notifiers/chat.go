@@ -0,0 +1,106 @@
+package notifiers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Event colors by priority, shared by the Discord and Slack notifiers.
+var priorityColors = map[string]int{
+	"low":      0x95a5a6,
+	"medium":   0x3498db,
+	"high":     0xe67e22,
+	"critical": 0xe74c3c,
+}
+
+// priorityColor returns the color of priority, medium for unknown ones.
+func priorityColor(priority string) int {
+	if c, ok := priorityColors[strings.ToLower(strings.TrimSpace(priority))]; ok {
+		return c
+	}
+	return priorityColors["medium"]
+}
+
+// field is one metadata entry rendered as a name/value pair.
+type field struct {
+	Name  string
+	Value string
+}
+
+// metadataFields renders meta as fields sorted by name.
+func metadataFields(meta map[string]any) []field {
+	fields := make([]field, 0, len(meta))
+	for k, v := range meta {
+		value := fmt.Sprint(v)
+		if b, err := json.Marshal(v); err == nil && !isScalar(v) {
+			value = string(b)
+		}
+		fields = append(fields, field{Name: k, Value: value})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields
+}
+
+func isScalar(v any) bool {
+	switch v.(type) {
+	case string, bool, int, int32, int64, uint, uint32, uint64, float32, float64, time.Time, time.Duration:
+		return true
+	}
+	return false
+}
+
+// truncate shortens s to at most limit runes, marking the cut with an ellipsis.
+func truncate(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:limit-1]) + "…"
+}
+
+// chunkText splits s into pieces of at most limit runes, preferring to break
+// at a newline, then at a space, in the second half of a piece.
+func chunkText(s string, limit int) []string {
+	return chunkTextWidth(s, limit, func(rune) int { return 1 })
+}
+
+// chunkTextWidth is chunkText with each rune counting width(r) towards limit,
+// for destinations whose escaping expands some characters.
+func chunkTextWidth(s string, limit int, width func(rune) int) []string {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return nil
+	}
+	var chunks []string
+	for {
+		n, w := 0, 0
+		for n < len(runes) && w+width(runes[n]) <= limit {
+			w += width(runes[n])
+			n++
+		}
+		if n == len(runes) {
+			break
+		}
+		n = max(n, 1)
+		cut := n
+		for _, sep := range []rune{'\n', ' '} {
+			found := false
+			for i := n; i > n/2; i-- {
+				if runes[i-1] == sep {
+					cut, found = i, true
+					break
+				}
+			}
+			if found {
+				break
+			}
+		}
+		chunks = append(chunks, strings.TrimRight(string(runes[:cut]), " \n"))
+		runes = runes[cut:]
+	}
+	return append(chunks, string(runes))
+}
@@ -0,0 +1,162 @@
+package notifiers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kubex-ecosystem/kbx/types"
+
+	gl "github.com/kubex-ecosystem/logz"
+)
+
+// DiscordNotifierName is the default name (and NotifierEvent.Type) of the Discord channel.
+const DiscordNotifierName = "discord"
+
+// Discord embed limits.
+const (
+	discordTitleLimit       = 256
+	discordDescriptionLimit = 4096
+	discordFieldLimit       = 25
+	discordFieldNameLimit   = 256
+	discordFieldValueLimit  = 1024
+	discordEmbedTotalLimit  = 6000
+)
+
+// DiscordConfig configures a DiscordNotifier.
+type DiscordConfig struct {
+	// Name overrides the channel name (DiscordNotifierName by default).
+	Name       string
+	WebhookURL string
+	// Username and AvatarURL override the webhook's identity.
+	Username  string
+	AvatarURL string
+	// Timeout bounds one attempt; Retries is the number of attempts per message.
+	Timeout time.Duration
+	Retries int
+	Client  *http.Client
+}
+
+// DiscordNotifier posts events to a Discord incoming webhook as embeds colored
+// by priority. Long content is split across several messages.
+type DiscordNotifier struct {
+	cfg    DiscordConfig
+	poster poster
+}
+
+type discordEmbed struct {
+	Title       string              `json:"title,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Color       int                 `json:"color"`
+	Fields      []discordEmbedField `json:"fields,omitempty"`
+	Footer      *discordEmbedFooter `json:"footer,omitempty"`
+	Timestamp   string              `json:"timestamp,omitempty"`
+}
+
+type discordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type discordEmbedFooter struct {
+	Text string `json:"text"`
+}
+
+type discordMessage struct {
+	Username  string         `json:"username,omitempty"`
+	AvatarURL string         `json:"avatar_url,omitempty"`
+	Embeds    []discordEmbed `json:"embeds"`
+	// AllowedMentions keeps event content from pinging @everyone or users.
+	AllowedMentions map[string][]string `json:"allowed_mentions"`
+}
+
+// NewDiscordNotifier validates cfg.
+func NewDiscordNotifier(cfg DiscordConfig) (*DiscordNotifier, error) {
+	if !strings.HasPrefix(cfg.WebhookURL, "https://") && !strings.HasPrefix(cfg.WebhookURL, "http://") {
+		return nil, gl.Errorf("invalid Discord webhook URL '%s'", cfg.WebhookURL)
+	}
+	return &DiscordNotifier{cfg: cfg, poster: newPoster(cfg.Client, cfg.Timeout, cfg.Retries, 0, 10*time.Second)}, nil
+}
+
+func (d *DiscordNotifier) Name() string {
+	if d.cfg.Name != "" {
+		return d.cfg.Name
+	}
+	return DiscordNotifierName
+}
+
+// Notify posts the event. Metadata becomes embed fields on the first message,
+// as many as the embed limits allow.
+func (d *DiscordNotifier) Notify(ctx context.Context, event types.NotifierEvent[any]) error {
+	subject := event.Subject(ctx)
+	if subject == "" {
+		subject = "Notification"
+	}
+	priority := event.Priority(ctx)
+	color := priorityColor(priority)
+	footer := strings.Trim(strings.Join([]string{priority, event.Type(ctx)}, " · "), " ·")
+	timestamp := ""
+	if created := event.CreatedAt(ctx); !created.IsZero() {
+		timestamp = created.UTC().Format(time.RFC3339)
+	}
+
+	chunks := chunkText(event.Content(ctx), discordDescriptionLimit)
+	if len(chunks) == 0 {
+		chunks = []string{""}
+	}
+	for i, chunk := range chunks {
+		title := subject
+		if len(chunks) > 1 {
+			title = fmt.Sprintf("%s (%d/%d)", subject, i+1, len(chunks))
+		}
+		embed := discordEmbed{
+			Title:       truncate(title, discordTitleLimit),
+			Description: chunk,
+			Color:       color,
+			Timestamp:   timestamp,
+		}
+		if footer != "" {
+			embed.Footer = &discordEmbedFooter{Text: footer}
+		}
+		if i == 0 {
+			embed.Fields = discordFields(event.Metadata(ctx), discordEmbedTotalLimit-utf8.RuneCountInString(embed.Title+embed.Description+footer))
+		}
+		msg := discordMessage{
+			Username:        d.cfg.Username,
+			AvatarURL:       d.cfg.AvatarURL,
+			Embeds:          []discordEmbed{embed},
+			AllowedMentions: map[string][]string{"parse": {}},
+		}
+		if err := d.poster.postJSON(ctx, d.cfg.WebhookURL, msg); err != nil {
+			return gl.Errorf("failed to post '%s' to Discord (message %d of %d): %v", subject, i+1, len(chunks), err)
+		}
+	}
+	return nil
+}
+
+// discordFields renders meta as embed fields within the count limit and the
+// characters left in the embed.
+func discordFields(meta map[string]any, budget int) []discordEmbedField {
+	var fields []discordEmbedField
+	for _, f := range metadataFields(meta) {
+		if len(fields) == discordFieldLimit {
+			break
+		}
+		name := truncate(f.Name, discordFieldNameLimit)
+		value := truncate(f.Value, discordFieldValueLimit)
+		if value == "" {
+			value = "-"
+		}
+		size := utf8.RuneCountInString(name + value)
+		if size > budget {
+			break
+		}
+		budget -= size
+		fields = append(fields, discordEmbedField{Name: name, Value: value, Inline: utf8.RuneCountInString(value) <= 40})
+	}
+	return fields
+}
@@ -0,0 +1,128 @@
+package notifiers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubex-ecosystem/kbx/tools"
+
+	gl "github.com/kubex-ecosystem/logz"
+)
+
+// maxRateLimitWait caps how long a single rate-limit response may hold a delivery.
+const maxRateLimitWait = time.Minute
+
+// poster POSTs JSON bodies to HTTP endpoints for the webhook and chat notifiers.
+type poster struct {
+	client     *http.Client
+	timeout    time.Duration
+	retries    int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+// newPoster fills the defaults of unset values: a plain client, a 10s attempt
+// timeout, 3 attempts and a 500ms backoff doubling up to maxBackoff (30s).
+func newPoster(client *http.Client, timeout time.Duration, retries int, backoff, maxBackoff time.Duration) poster {
+	if client == nil {
+		client = &http.Client{}
+	}
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	if retries <= 0 {
+		retries = 3
+	}
+	if backoff <= 0 {
+		backoff = 500 * time.Millisecond
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = 30 * time.Second
+	}
+	return poster{client: client, timeout: timeout, retries: retries, backoff: backoff, maxBackoff: maxBackoff}
+}
+
+// postJSON marshals payload and sends it to url.
+func (p poster) postJSON(ctx context.Context, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return gl.Errorf("failed to marshal webhook payload: %v", err)
+	}
+	return p.send(ctx, url, body, nil)
+}
+
+// send POSTs body to url; prepare, when set, adds headers to every attempt. A
+// 429 waits for the Retry-After header or the body's retry_after (seconds)
+// instead of the backoff; 5xx and network errors back off exponentially; other
+// failures are returned at once. No wait follows the last attempt.
+func (p poster) send(ctx context.Context, url string, body []byte, prepare func(*http.Request)) error {
+	_, err := tools.Retry(func() (struct{}, error) {
+		if err := ctx.Err(); err != nil {
+			return struct{}{}, tools.Permanent(err)
+		}
+		attemptCtx, cancel := context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(attemptCtx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return struct{}{}, tools.Permanent(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if prepare != nil {
+			prepare(req)
+		}
+
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return struct{}{}, err
+		}
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return struct{}{}, nil
+		}
+		statusErr := fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests:
+			wait := retryAfter(resp.Header, respBody)
+			if wait > maxRateLimitWait {
+				return struct{}{}, tools.Permanent(fmt.Errorf("rate limited for %s: %w", wait, statusErr))
+			}
+			return struct{}{}, tools.RetryAfter(statusErr, wait)
+		case resp.StatusCode >= 500:
+			return struct{}{}, statusErr
+		default:
+			return struct{}{}, tools.Permanent(statusErr)
+		}
+	},
+		tools.WithContext(ctx),
+		tools.WithRetries(p.retries),
+		tools.WithInitialDelay(p.backoff),
+		tools.WithBackoffFactor(2),
+		tools.WithMaxDelay(p.maxBackoff),
+	)
+	return err
+}
+
+// retryAfter reads the wait of a rate-limit response: the Retry-After header
+// or a JSON retry_after field, both in (possibly fractional) seconds.
+func retryAfter(h http.Header, body []byte) time.Duration {
+	if v := h.Get("Retry-After"); v != "" {
+		if sec, err := strconv.ParseFloat(v, 64); err == nil {
+			return time.Duration(sec * float64(time.Second))
+		}
+	}
+	var payload struct {
+		RetryAfter float64 `json:"retry_after"`
+	}
+	if json.Unmarshal(body, &payload) == nil && payload.RetryAfter > 0 {
+		return time.Duration(payload.RetryAfter * float64(time.Second))
+	}
+	return time.Second
+}
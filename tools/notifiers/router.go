@@ -0,0 +1,525 @@
+package notifiers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubex-ecosystem/kbx/types"
+
+	gl "github.com/kubex-ecosystem/logz"
+)
+
+// RouterName is the default name of a Router registered as a NotifierProvider.
+const RouterName = "router"
+
+// MetaDedupKey is the metadata key that, when set, replaces the computed deduplication key.
+const MetaDedupKey = "dedup_key"
+
+// Digest reasons, carried in a digest's Metadata["reason"].
+const (
+	DigestThrottled  = "throttled"
+	DigestQuietHours = "quiet_hours"
+)
+
+// digestTimeout bounds the delivery of a digest flushed in the background.
+const digestTimeout = 30 * time.Second
+
+var priorityRanks = map[string]int{"low": 0, "medium": 1, "high": 2, "critical": 3}
+
+// priorityRank orders priorities; unknown ones rank as medium.
+func priorityRank(priority string) int {
+	if rank, ok := priorityRanks[strings.ToLower(strings.TrimSpace(priority))]; ok {
+		return rank
+	}
+	return priorityRanks["medium"]
+}
+
+// RouteRule sends the events it matches to Channels. A rule matches when every
+// condition set holds.
+type RouteRule struct {
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// Priorities lists the priorities matched exactly.
+	Priorities []string `yaml:"priorities,omitempty" json:"priorities,omitempty"`
+	// MinPriority matches this priority and above.
+	MinPriority string `yaml:"min_priority,omitempty" json:"min_priority,omitempty"`
+	// Match compares metadata values (as text); "*" only requires the key.
+	Match map[string]string `yaml:"match,omitempty" json:"match,omitempty"`
+	// Channels are notifier names in the router's NotifierRegistry.
+	Channels []string `yaml:"channels" json:"channels"`
+	// Recipient replaces the event's recipient on these channels.
+	Recipient string `yaml:"recipient,omitempty" json:"recipient,omitempty"`
+	// Final stops evaluating the rules after this one when it matches.
+	Final bool `yaml:"final,omitempty" json:"final,omitempty"`
+}
+
+// QuietHours defers events of the listed priorities between Start and End
+// ("HH:MM", wrapping past midnight when End is not after Start). Deferred
+// events go out as one digest per channel and recipient when quiet hours end.
+type QuietHours struct {
+	Start string `yaml:"start" json:"start"`
+	End   string `yaml:"end" json:"end"`
+	// Location is the IANA zone of Start and End (local time when empty).
+	Location string `yaml:"location,omitempty" json:"location,omitempty"`
+	// Priorities are deferred; only "low" when empty.
+	Priorities []string `yaml:"priorities,omitempty" json:"priorities,omitempty"`
+}
+
+// RouterConfig configures a Router.
+type RouterConfig struct {
+	Rules []RouteRule `yaml:"rules,omitempty" json:"rules,omitempty"`
+	// DefaultChannels receive the events no rule matches.
+	DefaultChannels []string `yaml:"default_channels,omitempty" json:"default_channels,omitempty"`
+	// DedupWindow drops an event identical to one routed within the window.
+	DedupWindow time.Duration `yaml:"dedup_window,omitempty" json:"dedup_window,omitempty"`
+	// ThrottleLimit events per ThrottleWindow go out per channel and recipient;
+	// the excess is aggregated into a digest sent at the end of DigestWindow
+	// (ThrottleWindow when zero). No throttling when ThrottleLimit is zero.
+	ThrottleLimit  int           `yaml:"throttle_limit,omitempty" json:"throttle_limit,omitempty"`
+	ThrottleWindow time.Duration `yaml:"throttle_window,omitempty" json:"throttle_window,omitempty"`
+	DigestWindow   time.Duration `yaml:"digest_window,omitempty" json:"digest_window,omitempty"`
+	QuietHours     *QuietHours   `yaml:"quiet_hours,omitempty" json:"quiet_hours,omitempty"`
+}
+
+// Router is a rule engine in front of a NotifierRegistry. It is itself a
+// NotifierProvider, so it can be registered (under RouterName) in another
+// registry, such as the one the provider registry sends its events to.
+type Router struct {
+	reg   *types.NotifierRegistry
+	cfg   RouterConfig
+	quiet *quietWindow
+
+	mu      sync.Mutex
+	seen    map[string]time.Time
+	sent    map[string][]time.Time
+	digests map[string]*digest
+}
+
+// digest buffers the events held back for one channel and recipient.
+type digest struct {
+	channel   string
+	recipient string
+	reason    string
+	events    []types.NotifierConfig[any]
+	timer     *time.Timer
+}
+
+// NewRouter validates cfg and returns a router delivering through reg.
+func NewRouter(reg *types.NotifierRegistry, cfg RouterConfig) (*Router, error) {
+	if reg == nil {
+		return nil, gl.Errorf("notification router requires a notifier registry")
+	}
+	for i, rule := range cfg.Rules {
+		if len(rule.Channels) == 0 {
+			return nil, gl.Errorf("route rule %d (%s) has no channels", i, rule.Name)
+		}
+	}
+	if cfg.ThrottleLimit > 0 && cfg.ThrottleWindow <= 0 {
+		return nil, gl.Errorf("throttle limit requires a throttle window")
+	}
+	if cfg.DigestWindow <= 0 {
+		cfg.DigestWindow = cfg.ThrottleWindow
+	}
+	r := &Router{
+		reg:     reg,
+		cfg:     cfg,
+		seen:    make(map[string]time.Time),
+		sent:    make(map[string][]time.Time),
+		digests: make(map[string]*digest),
+	}
+	if cfg.QuietHours != nil {
+		quiet, err := newQuietWindow(*cfg.QuietHours)
+		if err != nil {
+			return nil, err
+		}
+		r.quiet = quiet
+	}
+	return r, nil
+}
+
+func (r *Router) Name() string { return RouterName }
+
+// Notify routes event to its channels. Duplicates are dropped, and events
+// that are throttled or fall in quiet hours are held for a digest; neither is
+// an error. The error reports failed immediate deliveries. An event reserves
+// its deduplication key up front, so concurrent duplicates are dropped too; the
+// key is released again if no route delivered or held it, so a failed event
+// can be sent again.
+func (r *Router) Notify(ctx context.Context, event types.NotifierEvent[any]) error {
+	cfg := eventConfig(ctx, event)
+	now := time.Now()
+
+	var key string
+	if r.cfg.DedupWindow > 0 {
+		key = dedupKey(cfg)
+		r.mu.Lock()
+		last, dup := r.seen[key]
+		dup = dup && now.Sub(last) < r.cfg.DedupWindow
+		if !dup {
+			r.seen[key] = now
+			r.pruneSeen(now)
+		}
+		r.mu.Unlock()
+		if dup {
+			gl.Debugf("Dropping duplicate notification '%s'", cfg.Subject)
+			return nil
+		}
+	}
+
+	routes := r.route(cfg)
+	if len(routes) == 0 {
+		r.release(key, now)
+		return gl.Errorf("no notification route for '%s' (priority %s)", cfg.Subject, cfg.Priority)
+	}
+
+	var errs []error
+	routed := false
+	for _, rt := range routes {
+		out := cfg
+		out.Type = rt.channel
+		if rt.recipient != "" {
+			out.Recipient = rt.recipient
+		}
+		if r.quiet != nil && r.quiet.defers(out.Priority) && r.quiet.contains(now) {
+			r.hold(out, DigestQuietHours, r.quiet.endAfter(now).Sub(now))
+			routed = true
+			continue
+		}
+		if !r.allow(out, now) {
+			r.hold(out, DigestThrottled, r.cfg.DigestWindow)
+			routed = true
+			continue
+		}
+		if err := r.reg.Dispatch(ctx, out).Wait(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", rt.channel, err))
+			continue
+		}
+		routed = true
+	}
+	if !routed {
+		r.release(key, now)
+	}
+	if len(errs) > 0 {
+		return gl.Errorf("notification '%s' failed on %d channel(s): %v", cfg.Subject, len(errs), errors.Join(errs...))
+	}
+	return nil
+}
+
+// Flush sends every pending digest now.
+func (r *Router) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	pending := make([]*digest, 0, len(r.digests))
+	for key, d := range r.digests {
+		d.timer.Stop()
+		delete(r.digests, key)
+		pending = append(pending, d)
+	}
+	r.mu.Unlock()
+
+	var errs []error
+	for _, d := range pending {
+		if err := r.sendDigest(ctx, d); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close flushes the pending digests; the router may still be used afterwards.
+func (r *Router) Close(ctx context.Context) error { return r.Flush(ctx) }
+
+type route struct {
+	channel   string
+	recipient string
+}
+
+// route evaluates the rules in order and returns the distinct destinations.
+func (r *Router) route(cfg types.NotifierConfig[any]) []route {
+	var routes []route
+	add := func(channels []string, recipient string) {
+		for _, ch := range channels {
+			rt := route{channel: ch, recipient: recipient}
+			if !slices.Contains(routes, rt) {
+				routes = append(routes, rt)
+			}
+		}
+	}
+	for _, rule := range r.cfg.Rules {
+		if !rule.matches(cfg) {
+			continue
+		}
+		add(rule.Channels, rule.Recipient)
+		if rule.Final {
+			break
+		}
+	}
+	if len(routes) == 0 {
+		add(r.cfg.DefaultChannels, "")
+	}
+	return routes
+}
+
+func (rule RouteRule) matches(cfg types.NotifierConfig[any]) bool {
+	priority := strings.ToLower(strings.TrimSpace(cfg.Priority))
+	if len(rule.Priorities) > 0 && !slices.ContainsFunc(rule.Priorities, func(p string) bool { return strings.EqualFold(p, priority) }) {
+		return false
+	}
+	if rule.MinPriority != "" && priorityRank(priority) < priorityRank(rule.MinPriority) {
+		return false
+	}
+	for key, want := range rule.Match {
+		got, ok := cfg.Metadata[key]
+		if !ok || (want != "*" && fmt.Sprint(got) != want) {
+			return false
+		}
+	}
+	return true
+}
+
+// allow records a send for the event's channel and recipient unless that
+// would exceed the throttle.
+func (r *Router) allow(cfg types.NotifierConfig[any], now time.Time) bool {
+	if r.cfg.ThrottleLimit <= 0 {
+		return true
+	}
+	key := cfg.Type + "\x00" + cfg.Recipient
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pruneSent(now)
+	recent := r.sent[key][:0]
+	for _, t := range r.sent[key] {
+		if now.Sub(t) < r.cfg.ThrottleWindow {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= r.cfg.ThrottleLimit {
+		r.sent[key] = recent
+		return false
+	}
+	r.sent[key] = append(recent, now)
+	return true
+}
+
+// hold adds cfg to the digest of its channel and recipient, starting the
+// digest's timer on the first event.
+func (r *Router) hold(cfg types.NotifierConfig[any], reason string, after time.Duration) {
+	key := reason + "\x00" + cfg.Type + "\x00" + cfg.Recipient
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.digests[key]
+	if !ok {
+		d = &digest{channel: cfg.Type, recipient: cfg.Recipient, reason: reason}
+		d.timer = time.AfterFunc(max(after, time.Millisecond), func() { r.flushDigest(key) })
+		r.digests[key] = d
+	}
+	d.events = append(d.events, cfg)
+}
+
+// flushDigest sends the digest stored under key from its timer.
+func (r *Router) flushDigest(key string) {
+	r.mu.Lock()
+	d, ok := r.digests[key]
+	delete(r.digests, key)
+	r.mu.Unlock()
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), digestTimeout)
+	defer cancel()
+	if err := r.sendDigest(ctx, d); err != nil {
+		gl.Warnf("Failed to send notification digest to '%s': %v", d.channel, err)
+	}
+}
+
+// sendDigest delivers the held events; a digest of one is sent as that event.
+func (r *Router) sendDigest(ctx context.Context, d *digest) error {
+	out := d.events[0]
+	if len(d.events) > 1 {
+		out = digestConfig(d)
+	}
+	if d.reason == DigestThrottled {
+		r.allow(out, time.Now())
+	}
+	return r.reg.Dispatch(ctx, out).Wait(ctx)
+}
+
+// digestConfig summarises the held events in one notification carrying the
+// highest of their priorities.
+func digestConfig(d *digest) types.NotifierConfig[any] {
+	priority := d.events[0].Priority
+	subjects := map[string]int{}
+	var lines []string
+	for _, ev := range d.events {
+		if priorityRank(ev.Priority) > priorityRank(priority) {
+			priority = ev.Priority
+		}
+		subjects[ev.Subject]++
+		line := ev.Subject
+		if first, _, _ := strings.Cut(strings.TrimSpace(ev.Content), "\n"); first != "" {
+			line += ": " + first
+		}
+		lines = append(lines, fmt.Sprintf("- [%s] %s %s", ev.CreatedAt.Format("15:04:05"), ev.Priority, line))
+	}
+
+	subject := fmt.Sprintf("%d notifications", len(d.events))
+	if len(subjects) == 1 {
+		subject = fmt.Sprintf("%s (x%d)", d.events[0].Subject, len(d.events))
+	}
+	if d.reason == DigestQuietHours {
+		subject += " during quiet hours"
+	}
+	return types.NotifierConfig[any]{
+		Type:      d.channel,
+		Recipient: d.recipient,
+		Subject:   subject,
+		Content:   strings.Join(lines, "\n"),
+		Priority:  priority,
+		Metadata:  map[string]any{"digest": true, "count": len(d.events), "reason": d.reason},
+		CreatedAt: time.Now(),
+	}
+}
+
+// release drops the dedup reservation Notify made for key at reserved, unless
+// a later event has reserved the key since.
+func (r *Router) release(key string, reserved time.Time) {
+	if key == "" {
+		return
+	}
+	r.mu.Lock()
+	if r.seen[key].Equal(reserved) {
+		delete(r.seen, key)
+	}
+	r.mu.Unlock()
+}
+
+// pruneSeen forgets dedup keys older than the window; r.mu must be held.
+func (r *Router) pruneSeen(now time.Time) {
+	if len(r.seen) < 1024 {
+		return
+	}
+	for key, t := range r.seen {
+		if now.Sub(t) >= r.cfg.DedupWindow {
+			delete(r.seen, key)
+		}
+	}
+}
+
+// pruneSent forgets throttle keys with no send inside the window; r.mu must be held.
+func (r *Router) pruneSent(now time.Time) {
+	if len(r.sent) < 1024 {
+		return
+	}
+	for key, times := range r.sent {
+		if len(times) == 0 || now.Sub(times[len(times)-1]) >= r.cfg.ThrottleWindow {
+			delete(r.sent, key)
+		}
+	}
+}
+
+// eventConfig copies the event's fields into a NotifierConfig.
+func eventConfig(ctx context.Context, event types.NotifierEvent[any]) types.NotifierConfig[any] {
+	if n, ok := event.(*types.Notification[any]); ok {
+		return n.Config()
+	}
+	created := event.CreatedAt(ctx)
+	if created.IsZero() {
+		created = time.Now()
+	}
+	return types.NotifierConfig[any]{
+		Type:      event.Type(ctx),
+		Recipient: event.Recipient(ctx),
+		Subject:   event.Subject(ctx),
+		Content:   event.Content(ctx),
+		Priority:  event.Priority(ctx),
+		Metadata:  event.Metadata(ctx),
+		CreatedAt: created,
+	}
+}
+
+// dedupKey identifies identical events: the MetaDedupKey metadata when set,
+// otherwise a hash of recipient, subject, content, priority and metadata.
+func dedupKey(cfg types.NotifierConfig[any]) string {
+	if key, ok := cfg.Metadata[MetaDedupKey]; ok {
+		return fmt.Sprint(key)
+	}
+	meta, _ := json.Marshal(cfg.Metadata)
+	sum := sha256.Sum256([]byte(strings.Join([]string{cfg.Recipient, cfg.Subject, cfg.Content, cfg.Priority, string(meta)}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// -------------------------------- QUIET HOURS --------------------------------
+
+type quietWindow struct {
+	start, end int // minutes since midnight, on the wall clock
+	loc        *time.Location
+	priorities []string
+}
+
+func newQuietWindow(q QuietHours) (*quietWindow, error) {
+	start, err := parseClock(q.Start)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseClock(q.End)
+	if err != nil {
+		return nil, err
+	}
+	if start == end {
+		return nil, gl.Errorf("quiet hours start and end are both %s", q.Start)
+	}
+	loc := time.Local
+	if q.Location != "" {
+		if loc, err = time.LoadLocation(q.Location); err != nil {
+			return nil, gl.Errorf("invalid quiet hours location '%s': %v", q.Location, err)
+		}
+	}
+	priorities := q.Priorities
+	if len(priorities) == 0 {
+		priorities = []string{"low"}
+	}
+	return &quietWindow{start: start, end: end, loc: loc, priorities: priorities}, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, gl.Errorf("invalid quiet hours time '%s', expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (q *quietWindow) defers(priority string) bool {
+	return slices.ContainsFunc(q.priorities, func(p string) bool { return strings.EqualFold(p, strings.TrimSpace(priority)) })
+}
+
+// at returns the wall-clock time clock (minutes since midnight) on the day of
+// now, shifted by days, in the window's location. Building it with time.Date
+// keeps it right on days when DST starts or ends.
+func (q *quietWindow) at(now time.Time, days, clock int) time.Time {
+	local := now.In(q.loc)
+	return time.Date(local.Year(), local.Month(), local.Day()+days, clock/60, clock%60, 0, 0, q.loc)
+}
+
+func (q *quietWindow) contains(now time.Time) bool {
+	start, end := q.at(now, 0, q.start), q.at(now, 0, q.end)
+	if q.start < q.end {
+		return !now.Before(start) && now.Before(end)
+	}
+	return !now.Before(start) || now.Before(end)
+}
+
+// endAfter returns when the quiet period containing now ends.
+func (q *quietWindow) endAfter(now time.Time) time.Time {
+	end := q.at(now, 0, q.end)
+	if !end.After(now) {
+		end = q.at(now, 1, q.end)
+	}
+	return end
+}
@@ -0,0 +1,170 @@
+package notifiers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kubex-ecosystem/kbx/types"
+
+	gl "github.com/kubex-ecosystem/logz"
+)
+
+// SlackNotifierName is the default name (and NotifierEvent.Type) of the Slack channel.
+const SlackNotifierName = "slack"
+
+// Slack Block Kit limits.
+const (
+	slackHeaderLimit        = 150
+	slackSectionLimit       = 3000
+	slackFieldLimit         = 2000
+	slackFieldsPerSection   = 10
+	slackMaxFieldSections   = 4
+	slackSectionsPerMessage = 40
+)
+
+// SlackConfig configures a SlackNotifier.
+type SlackConfig struct {
+	// Name overrides the channel name (SlackNotifierName by default).
+	Name       string
+	WebhookURL string
+	// Username, IconEmoji and Channel override the webhook defaults where the
+	// Slack app still allows it.
+	Username  string
+	IconEmoji string
+	Channel   string
+	// Timeout bounds one attempt; Retries is the number of attempts per message.
+	Timeout time.Duration
+	Retries int
+	Client  *http.Client
+}
+
+// SlackNotifier posts events to a Slack incoming webhook as Block Kit
+// attachments colored by priority. Long content is split across several messages.
+type SlackNotifier struct {
+	cfg    SlackConfig
+	poster poster
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackMessage struct {
+	Text        string            `json:"text"`
+	Username    string            `json:"username,omitempty"`
+	IconEmoji   string            `json:"icon_emoji,omitempty"`
+	Channel     string            `json:"channel,omitempty"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+// NewSlackNotifier validates cfg.
+func NewSlackNotifier(cfg SlackConfig) (*SlackNotifier, error) {
+	if !strings.HasPrefix(cfg.WebhookURL, "https://") && !strings.HasPrefix(cfg.WebhookURL, "http://") {
+		return nil, gl.Errorf("invalid Slack webhook URL '%s'", cfg.WebhookURL)
+	}
+	return &SlackNotifier{cfg: cfg, poster: newPoster(cfg.Client, cfg.Timeout, cfg.Retries, 0, 10*time.Second)}, nil
+}
+
+func (s *SlackNotifier) Name() string {
+	if s.cfg.Name != "" {
+		return s.cfg.Name
+	}
+	return SlackNotifierName
+}
+
+// Notify posts the event. The first message carries the header, the metadata
+// fields and the priority/type context line.
+func (s *SlackNotifier) Notify(ctx context.Context, event types.NotifierEvent[any]) error {
+	subject := event.Subject(ctx)
+	if subject == "" {
+		subject = "Notification"
+	}
+	color := fmt.Sprintf("#%06x", priorityColor(event.Priority(ctx)))
+
+	var sections []slackBlock
+	for _, chunk := range chunkTextWidth(event.Content(ctx), slackSectionLimit, slackEscapedWidth) {
+		sections = append(sections, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: slackEscape(chunk)}})
+	}
+
+	var messages [][]slackBlock
+	first := []slackBlock{{Type: "header", Text: &slackText{Type: "plain_text", Text: truncate(subject, slackHeaderLimit)}}}
+	n := min(len(sections), slackSectionsPerMessage)
+	first = append(first, sections[:n]...)
+	first = append(first, slackFieldBlocks(event.Metadata(ctx))...)
+	if footer := strings.Trim(strings.Join([]string{event.Priority(ctx), event.Type(ctx)}, " · "), " ·"); footer != "" {
+		first = append(first, slackBlock{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: slackEscape(footer)}}})
+	}
+	messages = append(messages, first)
+	for rest := sections[n:]; len(rest) > 0; {
+		n := min(len(rest), slackSectionsPerMessage)
+		messages = append(messages, rest[:n])
+		rest = rest[n:]
+	}
+
+	for i, blocks := range messages {
+		text := subject
+		if len(messages) > 1 {
+			text = fmt.Sprintf("%s (%d/%d)", subject, i+1, len(messages))
+		}
+		msg := slackMessage{
+			Text:        text,
+			Username:    s.cfg.Username,
+			IconEmoji:   s.cfg.IconEmoji,
+			Channel:     s.cfg.Channel,
+			Attachments: []slackAttachment{{Color: color, Blocks: blocks}},
+		}
+		if err := s.poster.postJSON(ctx, s.cfg.WebhookURL, msg); err != nil {
+			return gl.Errorf("failed to post '%s' to Slack (message %d of %d): %v", subject, i+1, len(messages), err)
+		}
+	}
+	return nil
+}
+
+// slackFieldBlocks renders meta as section fields, ten per section.
+func slackFieldBlocks(meta map[string]any) []slackBlock {
+	var blocks []slackBlock
+	for _, f := range metadataFields(meta) {
+		if len(blocks) == 0 || len(blocks[len(blocks)-1].Fields) == slackFieldsPerSection {
+			if len(blocks) == slackMaxFieldSections {
+				break
+			}
+			blocks = append(blocks, slackBlock{Type: "section"})
+		}
+		text := truncate("*"+slackEscape(f.Name)+"*\n"+slackEscape(f.Value), slackFieldLimit)
+		last := &blocks[len(blocks)-1]
+		last.Fields = append(last.Fields, slackText{Type: "mrkdwn", Text: text})
+	}
+	return blocks
+}
+
+// slackEscape escapes the characters Slack treats as markup delimiters.
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// slackEscapedWidth is the number of runes slackEscape turns r into.
+func slackEscapedWidth(r rune) int {
+	switch r {
+	case '&':
+		return len("&amp;")
+	case '<', '>':
+		return len("&lt;")
+	}
+	return 1
+}
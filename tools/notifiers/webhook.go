@@ -0,0 +1,208 @@
+// Package notifiers provides types.NotifierProvider implementations that push
+// notification events to HTTP endpoints.
+package notifiers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubex-ecosystem/kbx/types"
+
+	gl "github.com/kubex-ecosystem/logz"
+)
+
+// Webhook signature headers. The signature is "sha256=" followed by the hex
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the shared secret.
+const (
+	WebhookSignatureHeader = "X-Kbx-Signature"
+	WebhookTimestampHeader = "X-Kbx-Timestamp"
+	WebhookEventIDHeader   = "X-Kbx-Event-Id"
+)
+
+// WebhookNotifierName is the default name (and NotifierEvent.Type) of the webhook channel.
+const WebhookNotifierName = "webhook"
+
+// DefaultWebhookTolerance is the clock skew VerifyWebhookSignature accepts by default.
+const DefaultWebhookTolerance = 5 * time.Minute
+
+// WebhookConfig configures a WebhookNotifier.
+type WebhookConfig struct {
+	// Name overrides the channel name (WebhookNotifierName by default).
+	Name string
+	// URLs receive every event; delivery fails if any of them fails.
+	URLs []string
+	// Secret signs the body. Deliveries are unsigned when it is empty.
+	Secret []byte
+	// Headers are added to every request.
+	Headers map[string]string
+	// Timeout bounds one attempt (10s by default).
+	Timeout time.Duration
+	// Retries is the number of attempts per URL (3 by default).
+	Retries int
+	// Backoff is the delay before the second attempt (500ms by default); it
+	// doubles after each attempt up to MaxBackoff (30s by default).
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Client overrides the HTTP client.
+	Client *http.Client
+}
+
+// WebhookEnvelope is the JSON body POSTed for every event.
+type WebhookEnvelope struct {
+	ID        string         `json:"id"`
+	Type      string         `json:"type"`
+	Recipient string         `json:"recipient,omitempty"`
+	Subject   string         `json:"subject,omitempty"`
+	Content   string         `json:"content,omitempty"`
+	Priority  string         `json:"priority,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// WebhookNotifier POSTs a signed WebhookEnvelope of each event to the configured URLs.
+type WebhookNotifier struct {
+	cfg    WebhookConfig
+	poster poster
+}
+
+// NewWebhookNotifier validates cfg and fills its defaults.
+func NewWebhookNotifier(cfg WebhookConfig) (*WebhookNotifier, error) {
+	if len(cfg.URLs) == 0 {
+		return nil, gl.Errorf("webhook notifier requires at least one URL")
+	}
+	for _, u := range cfg.URLs {
+		if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+			return nil, gl.Errorf("invalid webhook URL '%s'", u)
+		}
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Retries <= 0 {
+		cfg.Retries = 3
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = 500 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 30 * time.Second
+	}
+	return &WebhookNotifier{cfg: cfg, poster: newPoster(cfg.Client, cfg.Timeout, cfg.Retries, cfg.Backoff, cfg.MaxBackoff)}, nil
+}
+
+func (w *WebhookNotifier) Name() string {
+	if w.cfg.Name != "" {
+		return w.cfg.Name
+	}
+	return WebhookNotifierName
+}
+
+// Notify delivers the event to every URL concurrently. Network errors and 5xx
+// responses are retried with backoff, 429 responses after their Retry-After;
+// other responses fail at once.
+func (w *WebhookNotifier) Notify(ctx context.Context, event types.NotifierEvent[any]) error {
+	ref := event.Ref()
+	envelope := WebhookEnvelope{
+		ID:        ref.ID.String(),
+		Type:      event.Type(ctx),
+		Recipient: event.Recipient(ctx),
+		Subject:   event.Subject(ctx),
+		Content:   event.Content(ctx),
+		Priority:  event.Priority(ctx),
+		Metadata:  event.Metadata(ctx),
+		CreatedAt: event.CreatedAt(ctx),
+	}
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return gl.Errorf("failed to marshal webhook envelope: %v", err)
+	}
+
+	errs := make([]error, len(w.cfg.URLs))
+	var wg sync.WaitGroup
+	for i, url := range w.cfg.URLs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = w.deliver(ctx, url, envelope.ID, body)
+		}()
+	}
+	wg.Wait()
+
+	var failed []string
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", w.cfg.URLs[i], err))
+		}
+	}
+	if len(failed) > 0 {
+		return gl.Errorf("webhook delivery failed for %d of %d URLs: %s", len(failed), len(w.cfg.URLs), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// deliver POSTs body to url, re-signing each attempt with a fresh timestamp.
+func (w *WebhookNotifier) deliver(ctx context.Context, url, id string, body []byte) error {
+	return w.poster.send(ctx, url, body, func(req *http.Request) {
+		for k, v := range w.cfg.Headers {
+			req.Header.Set(k, v)
+		}
+		req.Header.Set(WebhookEventIDHeader, id)
+		if len(w.cfg.Secret) > 0 {
+			ts := strconv.FormatInt(time.Now().Unix(), 10)
+			req.Header.Set(WebhookTimestampHeader, ts)
+			req.Header.Set(WebhookSignatureHeader, SignWebhook(w.cfg.Secret, ts, body))
+		}
+	})
+}
+
+// SignWebhook returns the signature header value for body sent at timestamp.
+func SignWebhook(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature checks signature against body and rejects timestamps
+// further than tolerance from now (DefaultWebhookTolerance when zero), which
+// blocks replays of captured requests.
+func VerifyWebhookSignature(secret []byte, timestamp, signature string, body []byte, tolerance time.Duration) error {
+	if tolerance <= 0 {
+		tolerance = DefaultWebhookTolerance
+	}
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return gl.Errorf("invalid webhook timestamp '%s'", timestamp)
+	}
+	if skew := time.Since(time.Unix(sec, 0)); skew > tolerance || skew < -tolerance {
+		return gl.Errorf("webhook timestamp outside the %s tolerance", tolerance)
+	}
+	if !hmac.Equal([]byte(SignWebhook(secret, timestamp, body)), []byte(signature)) {
+		return gl.Errorf("webhook signature mismatch")
+	}
+	return nil
+}
+
+// VerifyWebhookRequest reads and verifies a delivery on the receiving side,
+// returning its body. The request body is consumed.
+func VerifyWebhookRequest(r *http.Request, secret []byte, tolerance time.Duration) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, gl.Errorf("failed to read webhook body: %v", err)
+	}
+	if err := VerifyWebhookSignature(secret, r.Header.Get(WebhookTimestampHeader), r.Header.Get(WebhookSignatureHeader), body, tolerance); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
@@ -0,0 +1,148 @@
+package prompts
+
+import (
+	"embed"
+	"sync"
+
+	kbxTypes "github.com/kubex-ecosystem/kbx/types"
+
+	gl "github.com/kubex-ecosystem/logz"
+)
+
+//go:embed prompts
+var builtinFS embed.FS
+
+// Library resolves templates from in-memory registrations first and then from
+// its loaders, in the order they were added. Loaded templates are cached.
+type Library struct {
+	mu        sync.RWMutex
+	templates map[string]map[string]*Template
+	loaders   []TemplateLoader
+}
+
+var (
+	defaultLibrary     *Library
+	defaultLibraryOnce sync.Once
+)
+
+// NewLibrary creates a library backed by the given loaders.
+func NewLibrary(loaders ...TemplateLoader) *Library {
+	return &Library{
+		templates: make(map[string]map[string]*Template),
+		loaders:   loaders,
+	}
+}
+
+// Default returns the process-wide library with the built-in templates.
+func Default() *Library {
+	defaultLibraryOnce.Do(func() {
+		defaultLibrary = NewLibrary(&EmbedTemplateLoader{FS: builtinFS})
+	})
+	return defaultLibrary
+}
+
+// AddLoader appends a loader consulted after the existing ones.
+func (l *Library) AddLoader(loader TemplateLoader) {
+	if loader == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.loaders = append(l.loaders, loader)
+}
+
+// Register adds or replaces a template version in memory.
+func (l *Library) Register(tpl *Template) error {
+	if err := tpl.Validate(); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.templates[tpl.Name] == nil {
+		l.templates[tpl.Name] = make(map[string]*Template)
+	}
+	l.templates[tpl.Name][tpl.Version] = tpl
+	return nil
+}
+
+// Get returns a template by name and version. An empty version or
+// LatestVersion selects the highest version known to the library.
+func (l *Library) Get(name, version string) (*Template, error) {
+	if version == "" {
+		version = LatestVersion
+	}
+
+	l.mu.RLock()
+	if version != LatestVersion {
+		if tpl, ok := l.templates[name][version]; ok {
+			l.mu.RUnlock()
+			return tpl, nil
+		}
+	}
+	loaders := append([]TemplateLoader(nil), l.loaders...)
+	l.mu.RUnlock()
+
+	if version == LatestVersion {
+		versions := l.Versions(name)
+		if len(versions) == 0 {
+			return nil, gl.Errorf("prompt '%s' not found", name)
+		}
+		version = versions[len(versions)-1]
+		l.mu.RLock()
+		tpl, ok := l.templates[name][version]
+		l.mu.RUnlock()
+		if ok {
+			return tpl, nil
+		}
+	}
+
+	for _, loader := range loaders {
+		tpl, err := loader.LoadTemplate(name, version)
+		if err != nil {
+			continue
+		}
+		if err := l.Register(tpl); err != nil {
+			return nil, err
+		}
+		return tpl, nil
+	}
+	return nil, gl.Errorf("prompt '%s@%s' not found", name, version)
+}
+
+// Versions lists every known version of a template, ascending.
+func (l *Library) Versions(name string) []string {
+	set := map[string]struct{}{}
+
+	l.mu.RLock()
+	for v := range l.templates[name] {
+		set[v] = struct{}{}
+	}
+	loaders := append([]TemplateLoader(nil), l.loaders...)
+	l.mu.RUnlock()
+
+	for _, loader := range loaders {
+		vs, err := loader.Versions(name)
+		if err != nil {
+			continue
+		}
+		for _, v := range vs {
+			set[v] = struct{}{}
+		}
+	}
+
+	versions := make([]string, 0, len(set))
+	for v := range set {
+		versions = append(versions, v)
+	}
+	SortVersions(versions)
+	return versions
+}
+
+// Render resolves a template and renders it into chat messages.
+func (l *Library) Render(name, version string, vars map[string]any) ([]kbxTypes.Message, error) {
+	tpl, err := l.Get(name, version)
+	if err != nil {
+		return nil, err
+	}
+	return tpl.Render(vars)
+}
@@ -0,0 +1,181 @@
+package prompts
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	gl "github.com/kubex-ecosystem/logz"
+)
+
+// LatestVersion selects the highest available version of a template.
+const LatestVersion = "latest"
+
+var templateExts = []string{".yaml", ".yml", ".json"}
+
+// TemplateLoader defines how to obtain a prompt template definition.
+type TemplateLoader interface {
+	LoadTemplate(name, version string) (*Template, error)
+	Versions(name string) ([]string, error)
+}
+
+// EmbedTemplateLoader loads templates from an fs.FS such as embed.FS.
+// Expected layout: prompts/<template>/<version>.yaml.
+type EmbedTemplateLoader struct {
+	FS fs.FS
+}
+
+func (l *EmbedTemplateLoader) LoadTemplate(name, version string) (*Template, error) {
+	if l == nil || l.FS == nil {
+		return nil, gl.Errorf("prompt loader is nil")
+	}
+	return loadFromFS(l.FS, "prompts", name, version)
+}
+
+func (l *EmbedTemplateLoader) Versions(name string) ([]string, error) {
+	if l == nil || l.FS == nil {
+		return nil, gl.Errorf("prompt loader is nil")
+	}
+	return versionsFromFS(l.FS, path.Join("prompts", name))
+}
+
+// FileSystemTemplateLoader reads templates from a base directory.
+// Expected layout: <base>/<template>/<version>.yaml.
+type FileSystemTemplateLoader struct {
+	BasePath string
+}
+
+func (l *FileSystemTemplateLoader) LoadTemplate(name, version string) (*Template, error) {
+	if l == nil {
+		return nil, gl.Errorf("prompt loader is nil")
+	}
+	return loadFromFS(os.DirFS(l.BasePath), ".", name, version)
+}
+
+func (l *FileSystemTemplateLoader) Versions(name string) ([]string, error) {
+	if l == nil {
+		return nil, gl.Errorf("prompt loader is nil")
+	}
+	return versionsFromFS(os.DirFS(l.BasePath), name)
+}
+
+func loadFromFS(fsys fs.FS, root, name, version string) (*Template, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.Contains(name, "..") {
+		return nil, gl.Errorf("invalid prompt name '%s'", name)
+	}
+	dir := path.Join(root, name)
+
+	if version == "" || version == LatestVersion {
+		versions, err := versionsFromFS(fsys, dir)
+		if err != nil {
+			return nil, err
+		}
+		if len(versions) == 0 {
+			return nil, gl.Errorf("prompt '%s' has no versions", name)
+		}
+		version = versions[len(versions)-1]
+	}
+
+	for _, ext := range templateExts {
+		b, err := fs.ReadFile(fsys, path.Join(dir, version+ext))
+		if err != nil {
+			continue
+		}
+		tpl, err := ParseTemplate(b, ext)
+		if err != nil {
+			return nil, gl.Errorf("prompt '%s@%s': %v", name, version, err)
+		}
+		if tpl.Name == "" {
+			tpl.Name = name
+		}
+		if tpl.Version == "" {
+			tpl.Version = version
+		}
+		if err := tpl.Validate(); err != nil {
+			return nil, err
+		}
+		return tpl, nil
+	}
+	return nil, gl.Errorf("prompt '%s@%s' not found", name, version)
+}
+
+func versionsFromFS(fsys fs.FS, dir string) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		ext := path.Ext(e.Name())
+		for _, known := range templateExts {
+			if ext == known {
+				versions = append(versions, strings.TrimSuffix(e.Name(), ext))
+				break
+			}
+		}
+	}
+	SortVersions(versions)
+	return versions, nil
+}
+
+// ParseTemplate decodes a template definition. ext selects the format
+// (".json", otherwise YAML).
+func ParseTemplate(data []byte, ext string) (*Template, error) {
+	var tpl Template
+	var err error
+	if ext == ".json" {
+		err = json.Unmarshal(data, &tpl)
+	} else {
+		err = yaml.Unmarshal(data, &tpl)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tpl, nil
+}
+
+// SortVersions orders versions ascending, comparing dotted numeric segments
+// ("1.10.0" > "1.9.2") and falling back to string order.
+func SortVersions(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return compareVersions(versions[i], versions[j]) < 0
+	})
+}
+
+func compareVersions(a, b string) int {
+	pa := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	pb := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var sa, sb string
+		if i < len(pa) {
+			sa = pa[i]
+		}
+		if i < len(pb) {
+			sb = pb[i]
+		}
+		na, errA := strconv.Atoi(sa)
+		nb, errB := strconv.Atoi(sb)
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				if na < nb {
+					return -1
+				}
+				return 1
+			}
+		case sa != sb:
+			return strings.Compare(sa, sb)
+		}
+	}
+	return 0
+}
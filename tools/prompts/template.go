@@ -0,0 +1,258 @@
+// Package prompts provides named, versioned prompt templates rendered into chat messages.
+package prompts
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	kbxTypes "github.com/kubex-ecosystem/kbx/types"
+
+	gl "github.com/kubex-ecosystem/logz"
+)
+
+// VarType is the declared type of a template variable.
+type VarType string
+
+const (
+	VarString VarType = "string"
+	VarInt    VarType = "int"
+	VarFloat  VarType = "float"
+	VarBool   VarType = "bool"
+	VarList   VarType = "list"
+	VarAny    VarType = "any"
+)
+
+// Variable declares an input accepted by a Template.
+type Variable struct {
+	Name        string  `yaml:"name" json:"name"`
+	Type        VarType `yaml:"type,omitempty" json:"type,omitempty"`
+	Required    bool    `yaml:"required,omitempty" json:"required,omitempty"`
+	Default     any     `yaml:"default,omitempty" json:"default,omitempty"`
+	Description string  `yaml:"description,omitempty" json:"description,omitempty"`
+}
+
+// Template is a versioned prompt with system and user parts. Parts use
+// text/template syntax and see variables as {{ .name }}.
+type Template struct {
+	Name        string     `yaml:"name" json:"name"`
+	Version     string     `yaml:"version" json:"version"`
+	Description string     `yaml:"description,omitempty" json:"description,omitempty"`
+	Variables   []Variable `yaml:"variables,omitempty" json:"variables,omitempty"`
+	System      string     `yaml:"system,omitempty" json:"system,omitempty"`
+	User        string     `yaml:"user" json:"user"`
+}
+
+// MissingVariablesError reports required variables absent from a Render call.
+type MissingVariablesError struct {
+	Template string
+	Missing  []string
+}
+
+func (e *MissingVariablesError) Error() string {
+	return fmt.Sprintf("prompt '%s': missing required variables: %s", e.Template, strings.Join(e.Missing, ", "))
+}
+
+// Ref returns the "name@version" identifier of the template.
+func (t *Template) Ref() string { return t.Name + "@" + t.Version }
+
+// Validate checks the template definition itself: name, version, parts and
+// that every referenced part parses.
+func (t *Template) Validate() error {
+	if t == nil {
+		return gl.Errorf("prompt template is nil")
+	}
+	if strings.TrimSpace(t.Name) == "" {
+		return gl.Errorf("prompt template name is required")
+	}
+	if strings.TrimSpace(t.Version) == "" {
+		return gl.Errorf("prompt '%s': version is required", t.Name)
+	}
+	if strings.TrimSpace(t.User) == "" && strings.TrimSpace(t.System) == "" {
+		return gl.Errorf("prompt '%s': at least one of system or user must be set", t.Ref())
+	}
+	seen := make(map[string]struct{}, len(t.Variables))
+	for _, v := range t.Variables {
+		if strings.TrimSpace(v.Name) == "" {
+			return gl.Errorf("prompt '%s': variable without name", t.Ref())
+		}
+		if _, dup := seen[v.Name]; dup {
+			return gl.Errorf("prompt '%s': duplicated variable '%s'", t.Ref(), v.Name)
+		}
+		seen[v.Name] = struct{}{}
+		switch v.Type {
+		case "", VarString, VarInt, VarFloat, VarBool, VarList, VarAny:
+		default:
+			return gl.Errorf("prompt '%s': variable '%s' has unknown type '%s'", t.Ref(), v.Name, v.Type)
+		}
+	}
+	for part, text := range map[string]string{"system": t.System, "user": t.User} {
+		if _, err := template.New(part).Parse(text); err != nil {
+			return gl.Errorf("prompt '%s': invalid %s part: %v", t.Ref(), part, err)
+		}
+	}
+	return nil
+}
+
+// Render resolves variables (applying defaults and type checks) and renders
+// the template into a system message, when present, followed by a user message.
+func (t *Template) Render(vars map[string]any) ([]kbxTypes.Message, error) {
+	data, err := t.resolve(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]kbxTypes.Message, 0, 2)
+	if strings.TrimSpace(t.System) != "" {
+		content, err := execute(t.Ref()+"#system", t.System, data)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, kbxTypes.Message{Role: "system", Content: content})
+	}
+	if strings.TrimSpace(t.User) != "" {
+		content, err := execute(t.Ref()+"#user", t.User, data)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, kbxTypes.Message{Role: "user", Content: content})
+	}
+	return messages, nil
+}
+
+// RenderText renders the template and joins the parts into a single prompt,
+// for providers or callers that take one block of text.
+func (t *Template) RenderText(vars map[string]any) (string, error) {
+	messages, err := t.Render(vars)
+	if err != nil {
+		return "", err
+	}
+	parts := make([]string, 0, len(messages))
+	for _, m := range messages {
+		parts = append(parts, m.Content)
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+func (t *Template) resolve(vars map[string]any) (map[string]any, error) {
+	data := make(map[string]any, len(t.Variables)+len(vars))
+	for k, v := range vars {
+		data[k] = v
+	}
+
+	var missing []string
+	for _, decl := range t.Variables {
+		value, ok := data[decl.Name]
+		if !ok || value == nil {
+			if decl.Default != nil {
+				data[decl.Name] = decl.Default
+				continue
+			}
+			if decl.Required {
+				missing = append(missing, decl.Name)
+			} else {
+				data[decl.Name] = zeroValue(decl.Type)
+			}
+			continue
+		}
+		coerced, err := coerce(decl, value)
+		if err != nil {
+			return nil, gl.Errorf("prompt '%s': %v", t.Ref(), err)
+		}
+		data[decl.Name] = coerced
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, &MissingVariablesError{Template: t.Ref(), Missing: missing}
+	}
+	return data, nil
+}
+
+func execute(name, text string, data map[string]any) (string, error) {
+	parsed, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", gl.Errorf("prompt '%s': %v", name, err)
+	}
+	var buf bytes.Buffer
+	if err := parsed.Execute(&buf, data); err != nil {
+		return "", gl.Errorf("prompt '%s': %v", name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func coerce(decl Variable, value any) (any, error) {
+	switch decl.Type {
+	case "", VarAny:
+		return value, nil
+	case VarString:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case fmt.Stringer:
+			return v.String(), nil
+		}
+	case VarInt:
+		switch v := value.(type) {
+		case int:
+			return v, nil
+		case int32:
+			return int(v), nil
+		case int64:
+			return int(v), nil
+		case float64:
+			if v == float64(int(v)) {
+				return int(v), nil
+			}
+		case string:
+			if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+				return n, nil
+			}
+		}
+	case VarFloat:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case float32:
+			return float64(v), nil
+		case int:
+			return float64(v), nil
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f, nil
+			}
+		}
+	case VarBool:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return b, nil
+			}
+		}
+	case VarList:
+		switch v := value.(type) {
+		case []any, []string, []int, []float64:
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("variable '%s' expects %s, got %T", decl.Name, decl.Type, value)
+}
+
+func zeroValue(t VarType) any {
+	switch t {
+	case VarInt:
+		return 0
+	case VarFloat:
+		return 0.0
+	case VarBool:
+		return false
+	case VarList:
+		return []any{}
+	default:
+		return ""
+	}
+}
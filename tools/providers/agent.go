@@ -0,0 +1,377 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	kbxTypes "github.com/kubex-ecosystem/kbx/types"
+	jsonschema "github.com/santhosh-tekuri/jsonschema/v5"
+
+	gl "github.com/kubex-ecosystem/logz"
+)
+
+// Agent defaults, used when the matching AgentOptions field is zero.
+const (
+	DefaultAgentMaxIterations = 8
+	DefaultAgentParallelism   = 4
+	DefaultAgentToolTimeout   = 30 * time.Second
+)
+
+// Agent event types.
+const (
+	// AgentEventIteration opens a model turn.
+	AgentEventIteration = "iteration"
+	// AgentEventChunk forwards a content or reasoning chunk of the model.
+	AgentEventChunk = "chunk"
+	// AgentEventToolCall announces a tool about to run.
+	AgentEventToolCall = "tool_call"
+	// AgentEventToolResult carries the outcome of a tool run.
+	AgentEventToolResult = "tool_result"
+	// AgentEventDone ends a run with the final answer.
+	AgentEventDone = "done"
+	// AgentEventError ends a run that could not finish.
+	AgentEventError = "error"
+)
+
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// ToolFunc implements a tool. args is the JSON object sent by the model,
+// already validated against the tool's Parameters. The result is marshalled
+// to JSON (strings are sent as is) and fed back to the model.
+type ToolFunc func(ctx context.Context, args json.RawMessage) (any, error)
+
+// AgentTool is a Go function the model may call.
+type AgentTool struct {
+	Name        string
+	Description string
+	// Parameters is the JSON Schema of the arguments object.
+	Parameters map[string]any
+	Fn         ToolFunc
+	// Timeout overrides AgentOptions.ToolTimeout for this tool.
+	Timeout time.Duration
+
+	schema *jsonschema.Schema
+}
+
+// AgentOptions configures an Agent.
+type AgentOptions struct {
+	// MaxIterations bounds the model turns of one run.
+	MaxIterations int
+	// Parallelism bounds the tools running at once within a turn.
+	Parallelism int
+	// ToolTimeout bounds a single tool run.
+	ToolTimeout time.Duration
+}
+
+// AgentToolResult is the outcome of one tool call.
+type AgentToolResult struct {
+	CallID string `json:"call_id"`
+	Name   string `json:"name"`
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+	Ms     int64  `json:"ms"`
+}
+
+// AgentEvent is one step of an agent run's trajectory.
+type AgentEvent struct {
+	Type      string              `json:"type"`
+	Iteration int                 `json:"iteration"`
+	Chunk     *kbxTypes.ChatChunk `json:"chunk,omitempty"`
+	Call      *kbxTypes.ToolCall  `json:"call,omitempty"`
+	Result    *AgentToolResult    `json:"result,omitempty"`
+	Error     string              `json:"error,omitempty"`
+	// Usage sums every model turn so far.
+	Usage *kbxTypes.Usage `json:"usage,omitempty"`
+	// Content and Messages are set on done and error: the final answer and
+	// the whole conversation, tool calls and results included.
+	Content  string             `json:"content,omitempty"`
+	Messages []kbxTypes.Message `json:"messages,omitempty"`
+}
+
+// Agent runs the call-execute-reply loop over the registry: the model is asked
+// with the registered tools, requested tools are executed and their results
+// fed back until the model answers without calling any.
+type Agent struct {
+	reg  *Registry
+	opts AgentOptions
+
+	mu    sync.RWMutex
+	tools map[string]*AgentTool
+}
+
+// NewAgent returns an agent chatting through reg.
+func NewAgent(reg *Registry, opts AgentOptions) *Agent {
+	if opts.MaxIterations <= 0 {
+		opts.MaxIterations = DefaultAgentMaxIterations
+	}
+	if opts.Parallelism <= 0 {
+		opts.Parallelism = DefaultAgentParallelism
+	}
+	if opts.ToolTimeout <= 0 {
+		opts.ToolTimeout = DefaultAgentToolTimeout
+	}
+	return &Agent{reg: reg, opts: opts, tools: make(map[string]*AgentTool)}
+}
+
+// Register adds tool, replacing any tool of the same name. Its Parameters
+// schema is compiled up front so a bad schema fails here, not mid-run.
+func (a *Agent) Register(tool AgentTool) error {
+	if !toolNamePattern.MatchString(tool.Name) {
+		return gl.Errorf("invalid tool name '%s'", tool.Name)
+	}
+	if tool.Fn == nil {
+		return gl.Errorf("tool '%s' has no function", tool.Name)
+	}
+	if tool.Parameters == nil {
+		tool.Parameters = map[string]any{"type": "object", "properties": map[string]any{}}
+	}
+	raw, err := json.Marshal(tool.Parameters)
+	if err != nil {
+		return gl.Errorf("failed to marshal parameters of tool '%s': %v", tool.Name, err)
+	}
+	url := "tool://" + tool.Name + ".json"
+	comp := jsonschema.NewCompiler()
+	if err := comp.AddResource(url, bytes.NewReader(raw)); err != nil {
+		return gl.Errorf("invalid parameters schema of tool '%s': %v", tool.Name, err)
+	}
+	if tool.schema, err = comp.Compile(url); err != nil {
+		return gl.Errorf("invalid parameters schema of tool '%s': %v", tool.Name, err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.tools[tool.Name] = &tool
+	return nil
+}
+
+// Tools returns the specs of the registered tools, sorted by name.
+func (a *Agent) Tools() []kbxTypes.ToolSpec {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	specs := make([]kbxTypes.ToolSpec, 0, len(a.tools))
+	for _, tool := range a.tools {
+		specs = append(specs, kbxTypes.ToolSpec{Name: tool.Name, Description: tool.Description, Parameters: tool.Parameters})
+	}
+	slices.SortFunc(specs, func(x, y kbxTypes.ToolSpec) int { return strings.Compare(x.Name, y.Name) })
+	return specs
+}
+
+// Run starts the loop for req and streams its trajectory. The channel ends
+// with exactly one done or error event, unless ctx is cancelled first.
+// Tool failures are not fatal: they are reported to the model as the tool's result.
+func (a *Agent) Run(ctx context.Context, req kbxTypes.ChatRequest) (<-chan AgentEvent, error) {
+	if a.reg == nil {
+		return nil, gl.Errorf("agent has no registry")
+	}
+	if len(req.Messages) == 0 {
+		return nil, gl.Errorf("agent run requires at least one message")
+	}
+	req.Tools = a.Tools()
+	req.Messages = slices.Clone(req.Messages)
+
+	out := make(chan AgentEvent, 8)
+	go func() {
+		defer close(out)
+		emit := func(ev AgentEvent) bool {
+			select {
+			case out <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		usage := &kbxTypes.Usage{Provider: req.Provider, Model: req.Model}
+		// Events get a copy, so later turns do not change usage already handed out.
+		snapshot := func() *kbxTypes.Usage {
+			u := *usage
+			return &u
+		}
+		fail := func(iteration int, err string) {
+			emit(AgentEvent{Type: AgentEventError, Iteration: iteration, Error: err, Usage: snapshot(), Messages: req.Messages})
+		}
+
+		for iteration := 1; iteration <= a.opts.MaxIterations; iteration++ {
+			if !emit(AgentEvent{Type: AgentEventIteration, Iteration: iteration, Usage: snapshot()}) {
+				return
+			}
+			stream, err := a.reg.Chat(ctx, req)
+			if err != nil {
+				fail(iteration, err.Error())
+				return
+			}
+
+			var (
+				content strings.Builder
+				calls   []kbxTypes.ToolCall
+				failure string
+			)
+			for chunk := range stream {
+				switch {
+				case chunk.IsError():
+					if failure == "" {
+						failure = chunk.Error
+					}
+				case chunk.HasToolCall():
+					call := *chunk.ToolCall
+					if call.ID == "" {
+						call.ID = fmt.Sprintf("call_%d_%d", iteration, len(calls)+1)
+					}
+					calls = append(calls, call)
+				case chunk.HasContent() || chunk.HasReasoning():
+					content.WriteString(chunk.Content)
+					c := chunk
+					if !emit(AgentEvent{Type: AgentEventChunk, Iteration: iteration, Chunk: &c}) {
+						return
+					}
+				}
+				if chunk.Usage != nil {
+					addUsage(usage, chunk.Usage)
+					usage.Ms += chunk.Usage.Ms
+					if chunk.Usage.Provider != "" {
+						usage.Provider, usage.Model = chunk.Usage.Provider, chunk.Usage.Model
+					}
+				}
+			}
+			if failure != "" {
+				fail(iteration, failure)
+				return
+			}
+
+			req.Messages = append(req.Messages, kbxTypes.Message{Role: "assistant", Content: content.String(), ToolCalls: calls})
+			if len(calls) == 0 {
+				emit(AgentEvent{Type: AgentEventDone, Iteration: iteration, Usage: snapshot(), Content: content.String(), Messages: req.Messages})
+				return
+			}
+
+			for i := range calls {
+				if !emit(AgentEvent{Type: AgentEventToolCall, Iteration: iteration, Call: &calls[i]}) {
+					return
+				}
+			}
+			results := a.runTools(ctx, calls, func(res *AgentToolResult) {
+				emit(AgentEvent{Type: AgentEventToolResult, Iteration: iteration, Result: res})
+			})
+			if ctx.Err() != nil {
+				return
+			}
+			for _, res := range results {
+				msg := kbxTypes.Message{Role: "tool", ToolCallID: res.CallID, Name: res.Name, Content: res.Output}
+				if res.Error != "" {
+					payload, _ := json.Marshal(map[string]string{"error": res.Error})
+					msg.Content = string(payload)
+				}
+				req.Messages = append(req.Messages, msg)
+			}
+		}
+		fail(a.opts.MaxIterations, fmt.Sprintf("agent stopped after %d iterations without a final answer", a.opts.MaxIterations))
+	}()
+	return out, nil
+}
+
+// runTools executes calls with at most Parallelism running at once. Results
+// keep the order of calls; done is called as each one finishes.
+func (a *Agent) runTools(ctx context.Context, calls []kbxTypes.ToolCall, done func(*AgentToolResult)) []*AgentToolResult {
+	results := make([]*AgentToolResult, len(calls))
+	sem := make(chan struct{}, a.opts.Parallelism)
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i] = &AgentToolResult{CallID: call.ID, Name: call.Name, Error: ctx.Err().Error()}
+				return
+			}
+			results[i] = a.runTool(ctx, call)
+			done(results[i])
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// runTool validates the call's arguments and runs the tool under its timeout.
+func (a *Agent) runTool(ctx context.Context, call kbxTypes.ToolCall) *AgentToolResult {
+	res := &AgentToolResult{CallID: call.ID, Name: call.Name}
+	start := time.Now()
+	defer func() { res.Ms = time.Since(start).Milliseconds() }()
+
+	a.mu.RLock()
+	tool, ok := a.tools[call.Name]
+	a.mu.RUnlock()
+	if !ok {
+		res.Error = fmt.Sprintf("unknown tool '%s'", call.Name)
+		return res
+	}
+
+	args := json.RawMessage(toolArgsString(call.Args))
+	dec := json.NewDecoder(bytes.NewReader(args))
+	dec.UseNumber()
+	var decoded any
+	if err := dec.Decode(&decoded); err != nil {
+		res.Error = fmt.Sprintf("arguments are not valid JSON: %v", err)
+		return res
+	}
+	if err := tool.schema.Validate(decoded); err != nil {
+		res.Error = fmt.Sprintf("invalid arguments: %v", err)
+		return res
+	}
+
+	timeout := tool.Timeout
+	if timeout <= 0 {
+		timeout = a.opts.ToolTimeout
+	}
+	toolCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		value any
+		err   error
+	}
+	ch := make(chan outcome, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				ch <- outcome{err: fmt.Errorf("tool panicked: %v", p)}
+			}
+		}()
+		value, err := tool.Fn(toolCtx, args)
+		ch <- outcome{value: value, err: err}
+	}()
+
+	// A tool ignoring its context is abandoned once the timeout passes.
+	select {
+	case o := <-ch:
+		if o.err != nil {
+			res.Error = o.err.Error()
+			return res
+		}
+		if s, ok := o.value.(string); ok {
+			res.Output = s
+			return res
+		}
+		payload, err := json.Marshal(o.value)
+		if err != nil {
+			res.Error = fmt.Sprintf("failed to marshal tool result: %v", err)
+			return res
+		}
+		res.Output = string(payload)
+	case <-toolCtx.Done():
+		if ctx.Err() != nil {
+			res.Error = ctx.Err().Error()
+		} else {
+			res.Error = fmt.Sprintf("tool timed out after %s", timeout)
+		}
+	}
+	return res
+}
@@ -1,7 +1,6 @@
 package registry
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -13,6 +12,8 @@ import (
 	"sync"
 	"time"
 
+	kbxMod "github.com/kubex-ecosystem/kbx/internal/module/kbx"
+	"github.com/kubex-ecosystem/kbx/tools/providers/sse"
 	providers "github.com/kubex-ecosystem/kbx/types"
 	gl "github.com/kubex-ecosystem/logz"
 )
@@ -46,9 +47,7 @@ func NewAnthropicProvider(name, baseURL, key, model string) (providers.ProviderE
 		apiKey:            key,
 		defaultModel:      model,
 		baseURL:           baseURL,
-		client: &http.Client{
-			Timeout: time.Minute * 5,
-		},
+		client:            newStreamingClient(),
 	}, nil
 }
 
@@ -56,6 +55,11 @@ func (p *anthropicProvider) Name() string {
 	return p.name
 }
 
+// ModelInfo describes the default model, including its capability descriptor.
+func (p *anthropicProvider) ModelInfo(ctx context.Context) (map[string]any, error) {
+	return modelInfo(p.name, "anthropic", p.defaultModel, nil), nil
+}
+
 func (p *anthropicProvider) Available() error {
 	if p.apiKey == "" {
 		return errors.New("anthropic API key not configured")
@@ -66,7 +70,25 @@ func (p *anthropicProvider) Available() error {
 // anthropicMessage represents a message in Anthropic's format
 type anthropicMessage struct {
 	Role    string `json:"role"`
-	Content string `json:"content"`
+	Content any    `json:"content"` // string, or []anthropicBlock for tool use
+}
+
+// anthropicBlock is a content block used for tool calls and their results
+type anthropicBlock struct {
+	Type      string `json:"type"`
+	Text      string `json:"text,omitempty"`
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Input     any    `json:"input,omitempty"`
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+}
+
+// anthropicTool declares a tool the model may call
+type anthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"input_schema"`
 }
 
 // anthropicRequest represents the request to Anthropic API
@@ -77,6 +99,17 @@ type anthropicRequest struct {
 	Stream    bool               `json:"stream"`
 	System    string             `json:"system,omitempty"`
 	Temp      float32            `json:"temperature,omitempty"`
+	TopP      *float32           `json:"top_p,omitempty"`
+	TopK      *int               `json:"top_k,omitempty"`
+	Stop      []string           `json:"stop_sequences,omitempty"`
+	Thinking  *anthropicThinking `json:"thinking,omitempty"`
+	Tools     []anthropicTool    `json:"tools,omitempty"`
+}
+
+// anthropicThinking enables extended thinking with a token budget
+type anthropicThinking struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens"`
 }
 
 // anthropicResponse represents the response from Anthropic API
@@ -95,10 +128,18 @@ type anthropicResponse struct {
 
 // anthropicStreamEvent represents a streaming event from Anthropic
 type anthropicStreamEvent struct {
-	Type  string `json:"type"`
-	Delta struct {
+	Type         string `json:"type"`
+	Index        int    `json:"index"`
+	ContentBlock struct {
 		Type string `json:"type"`
-		Text string `json:"text"`
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"content_block"`
+	Delta struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		Thinking    string `json:"thinking"`
+		PartialJSON string `json:"partial_json"`
 	} `json:"delta"`
 	Usage struct {
 		InputTokens  int `json:"input_tokens"`
@@ -128,10 +169,35 @@ func (p *anthropicProvider) Chat(ctx context.Context, req providers.ChatRequest)
 	for _, msg := range req.Messages {
 		switch msg.Role {
 		case "user", "assistant":
-			messages = append(messages, anthropicMessage{
-				Role:    msg.Role,
-				Content: msg.Content,
-			})
+			if len(msg.ToolCalls) == 0 {
+				messages = append(messages, anthropicMessage{
+					Role:    msg.Role,
+					Content: msg.Content,
+				})
+				continue
+			}
+			blocks := []anthropicBlock{}
+			if msg.Content != "" {
+				blocks = append(blocks, anthropicBlock{Type: "text", Text: msg.Content})
+			}
+			for _, call := range msg.ToolCalls {
+				input := call.Args
+				if s, ok := input.(string); ok || input == nil {
+					input = parseToolArgs(s)
+				}
+				blocks = append(blocks, anthropicBlock{Type: "tool_use", ID: call.ID, Name: call.Name, Input: input})
+			}
+			messages = append(messages, anthropicMessage{Role: msg.Role, Content: blocks})
+		case "tool":
+			// Tool results go back as user messages; consecutive results share one.
+			result := anthropicBlock{Type: "tool_result", ToolUseID: msg.ToolCallID, Content: msg.Content}
+			if n := len(messages); n > 0 && messages[n-1].Role == "user" {
+				if blocks, ok := messages[n-1].Content.([]anthropicBlock); ok {
+					messages[n-1].Content = append(blocks, result)
+					continue
+				}
+			}
+			messages = append(messages, anthropicMessage{Role: "user", Content: []anthropicBlock{result}})
 		case "system":
 			// Anthropic handles system messages separately
 			systemMessage = msg.Content
@@ -144,20 +210,64 @@ func (p *anthropicProvider) Chat(ctx context.Context, req providers.ChatRequest)
 		model = p.defaultModel
 	}
 
+	// max_tokens is mandatory for Anthropic: fall back to the model's output limit.
+	maxTokens := req.MaxTokens
+	if maxTokens == 0 {
+		if caps, ok := lookupCapabilities("anthropic", model, nil); ok && caps.MaxOutputTokens > 0 {
+			maxTokens = caps.MaxOutputTokens
+		} else {
+			maxTokens = kbxMod.DefaultLLMMaxTokens
+		}
+	}
+
 	anthropicReq := anthropicRequest{
 		Model:     model,
-		MaxTokens: 4096,
+		MaxTokens: maxTokens,
 		Messages:  messages,
 		Stream:    true,
+		TopK:      optionalInt(req.TopK),
+		Stop:      req.Stop,
 	}
+	warnUnsupportedParams(p.name, req, ParamMaxTokens, ParamTopP, ParamTopK, ParamStop)
 
 	if systemMessage != "" {
 		anthropicReq.System = systemMessage
 	}
+	for _, spec := range req.Tools {
+		schema := spec.Parameters
+		if schema == nil {
+			schema = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		anthropicReq.Tools = append(anthropicReq.Tools, anthropicTool{Name: spec.Name, Description: spec.Description, InputSchema: schema})
+	}
 
 	if req.Temp > 0 {
 		anthropicReq.Temp = req.Temp
 	}
+	// Recent Claude models reject temperature and top_p together; temperature wins.
+	if req.TopP > 0 && req.Temp == 0 {
+		anthropicReq.TopP = optionalFloat(req.TopP)
+	} else if req.TopP > 0 {
+		gl.Debugf("Provider '%s': dropping top_p because temperature is set", p.name)
+	}
+
+	// Extended thinking: max_tokens covers thinking plus answer, and sampling
+	// overrides are not allowed while thinking. With tools, Anthropic requires the
+	// signed thinking blocks to be replayed alongside each tool_use turn; messages
+	// do not carry them, so thinking is turned off for tool conversations.
+	budget := reasoningBudget(req)
+	if budget > 0 && usesTools(req) {
+		gl.Warnf("Provider '%s': extended thinking is not supported with tool use; disabling it", p.name)
+		budget = 0
+	}
+	if budget > 0 {
+		budget = max(budget, 1024)
+		anthropicReq.Thinking = &anthropicThinking{Type: "enabled", BudgetTokens: budget}
+		anthropicReq.MaxTokens += budget
+		anthropicReq.Temp = 0
+		anthropicReq.TopP = nil
+		anthropicReq.TopK = nil
+	}
 
 	// Create request body
 	reqBody, err := json.Marshal(anthropicReq)
@@ -176,6 +286,7 @@ func (p *anthropicProvider) Chat(ctx context.Context, req providers.ChatRequest)
 	httpReq.Header.Set("x-api-key", p.apiKey)
 	httpReq.Header.Set("anthropic-version", "2023-06-01")
 	httpReq.Header.Set("Accept", "text/event-stream")
+	applyRequestHeaders(httpReq, req.Headers)
 
 	// Create response channel
 	responseChan := make(chan providers.ChatChunk, 100)
@@ -212,43 +323,78 @@ func (p *anthropicProvider) Chat(ctx context.Context, req providers.ChatRequest)
 		}
 
 		// Handle streaming response
-		scanner := bufio.NewScanner(resp.Body)
-		for scanner.Scan() {
-			line := scanner.Text()
-
-			// Skip empty lines and non-data lines
-			if !strings.HasPrefix(line, "data: ") {
-				continue
+		reader := sse.NewReader(resp.Body)
+		// Tool calls in progress by content block index; their input arrives as JSON fragments.
+		type pendingCall struct {
+			id, name string
+			input    strings.Builder
+		}
+		pending := map[int]*pendingCall{}
+		for {
+			sseEvent, err := reader.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				responseChan <- providers.ChatChunk{
+					Content: "",
+					Done:    true,
+					Error:   fmt.Sprintf("Stream reading error: %v", err),
+				}
+				return
 			}
 
-			// Remove "data: " prefix
-			data := strings.TrimPrefix(line, "data: ")
-
-			// Skip heartbeat
-			if data == "[DONE]" {
+			// Check for end of stream
+			if sseEvent.IsDone() {
 				break
 			}
 
 			// Parse event
 			var event anthropicStreamEvent
-			if err := json.Unmarshal([]byte(data), &event); err != nil {
+			if err := json.Unmarshal([]byte(sseEvent.Data), &event); err != nil {
 				continue // Skip invalid JSON
 			}
 
 			// Handle different event types
 			switch event.Type {
+			case "content_block_start":
+				if event.ContentBlock.Type == "tool_use" {
+					pending[event.Index] = &pendingCall{id: event.ContentBlock.ID, name: event.ContentBlock.Name}
+				}
+
 			case "content_block_delta":
-				if event.Delta.Type == "text_delta" {
-					chunk := providers.ChatChunk{
-						Content: event.Delta.Text,
-						Done:    false,
+				var chunk providers.ChatChunk
+				switch event.Delta.Type {
+				case "text_delta":
+					chunk.Content = event.Delta.Text
+				case "thinking_delta":
+					chunk.Reasoning = event.Delta.Thinking
+				case "input_json_delta":
+					if call, ok := pending[event.Index]; ok {
+						call.input.WriteString(event.Delta.PartialJSON)
 					}
+					continue
+				default:
+					continue
+				}
 
-					select {
-					case responseChan <- chunk:
-					case <-ctx.Done():
-						return
-					}
+				select {
+				case responseChan <- chunk:
+				case <-ctx.Done():
+					return
+				}
+
+			case "content_block_stop":
+				call, ok := pending[event.Index]
+				if !ok {
+					continue
+				}
+				delete(pending, event.Index)
+				toolCall := &providers.ToolCall{ID: call.id, Name: call.name, Args: parseToolArgs(call.input.String())}
+				select {
+				case responseChan <- providers.ChatChunk{ToolCall: toolCall}:
+				case <-ctx.Done():
+					return
 				}
 
 			case "message_start":
@@ -263,15 +409,6 @@ func (p *anthropicProvider) Chat(ctx context.Context, req providers.ChatRequest)
 			}
 		}
 
-		if err := scanner.Err(); err != nil {
-			responseChan <- providers.ChatChunk{
-				Content: "",
-				Done:    true,
-				Error:   fmt.Sprintf("Stream reading error: %v", err),
-			}
-			return
-		}
-
 		// Calculate final metrics
 		totalTokens = inputTokens + outputTokens
 		latencyMs := time.Since(startTime).Milliseconds()
@@ -337,3 +474,16 @@ func calculateAnthropicCost(model string, inputTokens, outputTokens int) float64
 
 	return float64(inputTokens)*inputRate + float64(outputTokens)*outputRate
 }
+
+// usesTools reports whether req offers tools or replays tool turns.
+func usesTools(req providers.ChatRequest) bool {
+	if len(req.Tools) > 0 {
+		return true
+	}
+	for _, m := range req.Messages {
+		if len(m.ToolCalls) > 0 || m.ToolCallID != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,298 @@
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubex-ecosystem/kbx/tools/security/crypto"
+	kbxTypes "github.com/kubex-ecosystem/kbx/types"
+
+	gl "github.com/kubex-ecosystem/logz"
+)
+
+// MetaUserID is the ChatRequest.Meta key carrying the end user recorded in the audit log.
+const MetaUserID = "user"
+
+// AuditRecord is one line of the audit log. Hash covers every other field,
+// including PrevHash, so editing, dropping or reordering a record breaks the chain.
+type AuditRecord struct {
+	Seq         uint64          `json:"seq"`
+	Time        time.Time       `json:"time"`
+	RequestHash string          `json:"requestHash"`
+	Tenant      string          `json:"tenant,omitempty"`
+	User        string          `json:"user,omitempty"`
+	Provider    string          `json:"provider"`
+	Model       string          `json:"model"`
+	Usage       *kbxTypes.Usage `json:"usage,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	// Content is the encrypted AuditContent, present only when the log has a key.
+	Content  string `json:"content,omitempty"`
+	PrevHash string `json:"prevHash"`
+	Hash     string `json:"hash"`
+}
+
+// AuditContent is the prompt and response stored encrypted in AuditRecord.Content.
+type AuditContent struct {
+	Messages []kbxTypes.Message `json:"messages"`
+	Response string             `json:"response"`
+}
+
+// AuditOptions configures an AuditLog.
+type AuditOptions struct {
+	// Key enables content capture; prompts and responses are encrypted with
+	// crypto.CryptoService. Without a key only metadata is recorded.
+	Key []byte
+	// Sync fsyncs the file after every record.
+	Sync bool
+}
+
+// AuditLog is an append-only, hash-chained JSONL file of completed requests.
+type AuditLog struct {
+	mu       sync.Mutex
+	file     *os.File
+	opts     AuditOptions
+	crypto   *crypto.CryptoService
+	seq      uint64
+	lastHash string
+}
+
+// OpenAuditLog opens (or creates) path for appending and resumes the chain
+// from its last record.
+func OpenAuditLog(path string, opts AuditOptions) (*AuditLog, error) {
+	seq, lastHash, err := lastAuditRecord(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, gl.Errorf("failed to open audit log: %v", err)
+	}
+	return &AuditLog{file: f, opts: opts, crypto: crypto.NewCryptoService(), seq: seq, lastHash: lastHash}, nil
+}
+
+// Append chains rec to the log, filling Seq, Time (if unset), PrevHash and Hash.
+func (a *AuditLog) Append(rec AuditRecord) (AuditRecord, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	rec.Seq = a.seq + 1
+	if rec.Time.IsZero() {
+		rec.Time = time.Now().UTC()
+	}
+	rec.PrevHash = a.lastHash
+	rec.Hash = ""
+	hash, err := hashAuditRecord(rec)
+	if err != nil {
+		return rec, err
+	}
+	rec.Hash = hash
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return rec, gl.Errorf("failed to marshal audit record: %v", err)
+	}
+	if _, err := a.file.Write(append(line, '\n')); err != nil {
+		return rec, gl.Errorf("failed to write audit record: %v", err)
+	}
+	if a.opts.Sync {
+		if err := a.file.Sync(); err != nil {
+			return rec, gl.Errorf("failed to sync audit log: %v", err)
+		}
+	}
+	a.seq, a.lastHash = rec.Seq, rec.Hash
+	return rec, nil
+}
+
+// Head returns the sequence number and hash of the last record. Storing it
+// elsewhere lets VerifyAuditLog catch truncation of the file's tail.
+func (a *AuditLog) Head() (uint64, string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.seq, a.lastHash
+}
+
+func (a *AuditLog) Close() error { return a.file.Close() }
+
+// Record appends the outcome of one completed request.
+func (a *AuditLog) Record(req kbxTypes.ChatRequest, arm ArmResult) error {
+	rec := AuditRecord{
+		RequestHash: hashChatRequest(req),
+		Tenant:      tenantOf(req),
+		Provider:    arm.Provider,
+		Model:       arm.Model,
+		Usage:       arm.Usage,
+		Error:       arm.Error,
+	}
+	rec.User, _ = req.Meta[MetaUserID].(string)
+	// The served provider/model may differ from the requested one (defaults, routing).
+	if arm.Usage != nil {
+		if arm.Usage.Provider != "" {
+			rec.Provider = arm.Usage.Provider
+		}
+		if arm.Usage.Model != "" {
+			rec.Model = arm.Usage.Model
+		}
+	}
+
+	if len(a.opts.Key) > 0 {
+		payload, err := json.Marshal(AuditContent{Messages: req.Messages, Response: arm.Content})
+		if err != nil {
+			return gl.Errorf("failed to marshal audit content: %v", err)
+		}
+		if rec.Content, err = a.crypto.EncryptBytes(payload, a.opts.Key); err != nil {
+			return gl.Errorf("failed to encrypt audit content: %v", err)
+		}
+	}
+
+	_, err := a.Append(rec)
+	return err
+}
+
+// AuditMiddleware writes one record to log for every request once its stream ends.
+func AuditMiddleware(log *AuditLog) Middleware {
+	return func(next ChatHandler) ChatHandler {
+		return func(ctx context.Context, req kbxTypes.ChatRequest) (<-chan kbxTypes.ChatChunk, error) {
+			if log == nil {
+				return next(ctx, req)
+			}
+			stream, err := next(ctx, req)
+			if err != nil {
+				if recErr := log.Record(req, ArmResult{Provider: req.Provider, Model: req.Model, Error: err.Error()}); recErr != nil {
+					gl.Warnf("Audit log write failed: %v", recErr)
+				}
+				return nil, err
+			}
+			return observeArm(ctx, stream, req, func(arm ArmResult) {
+				if recErr := log.Record(req, arm); recErr != nil {
+					gl.Warnf("Audit log write failed: %v", recErr)
+				}
+			}), nil
+		}
+	}
+}
+
+// DecryptAuditContent opens the encrypted content of rec with key.
+func DecryptAuditContent(rec AuditRecord, key []byte) (*AuditContent, error) {
+	if rec.Content == "" {
+		return nil, gl.Errorf("audit record %d has no content", rec.Seq)
+	}
+	payload, err := crypto.NewCryptoService().DecryptBytes(rec.Content, key)
+	if err != nil {
+		return nil, err
+	}
+	content := &AuditContent{}
+	if err := json.Unmarshal(payload, content); err != nil {
+		return nil, gl.Errorf("failed to decode audit content: %v", err)
+	}
+	return content, nil
+}
+
+// -------------------------------- VERIFICATION --------------------------------
+
+// AuditVerifyError locates the first break in the chain.
+type AuditVerifyError struct {
+	Line   int
+	Seq    uint64
+	Reason string
+}
+
+func (e *AuditVerifyError) Error() string {
+	return fmt.Sprintf("audit log broken at line %d (seq %d): %s", e.Line, e.Seq, e.Reason)
+}
+
+// AuditReport summarises a verified log.
+type AuditReport struct {
+	Records  int
+	LastSeq  uint64
+	LastHash string
+}
+
+// VerifyAuditLog walks the log and checks sequence numbers, chain links and
+// record hashes. It returns an *AuditVerifyError for the first gap or edit.
+// Removing records from the end keeps the remaining chain valid; compare the
+// report with a Head stored elsewhere to detect that.
+func VerifyAuditLog(path string) (AuditReport, error) {
+	report := AuditReport{}
+	f, err := os.Open(path)
+	if err != nil {
+		return report, gl.Errorf("failed to open audit log: %v", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	for line := 1; ; line++ {
+		raw, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) && len(raw) == 0 {
+			return report, nil
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return report, gl.Errorf("failed to read audit log: %v", err)
+		}
+
+		var rec AuditRecord
+		if jsonErr := json.Unmarshal(bytes.TrimSpace(raw), &rec); jsonErr != nil {
+			return report, &AuditVerifyError{Line: line, Seq: report.LastSeq + 1, Reason: "malformed record: " + jsonErr.Error()}
+		}
+		if rec.Seq != report.LastSeq+1 {
+			return report, &AuditVerifyError{Line: line, Seq: rec.Seq, Reason: fmt.Sprintf("expected seq %d", report.LastSeq+1)}
+		}
+		if rec.PrevHash != report.LastHash {
+			return report, &AuditVerifyError{Line: line, Seq: rec.Seq, Reason: "previous hash does not match"}
+		}
+		stored := rec.Hash
+		rec.Hash = ""
+		if hash, _ := hashAuditRecord(rec); hash != stored {
+			return report, &AuditVerifyError{Line: line, Seq: rec.Seq, Reason: "record hash does not match its contents"}
+		}
+
+		report.Records++
+		report.LastSeq, report.LastHash = rec.Seq, stored
+		if errors.Is(err, io.EOF) {
+			return report, nil
+		}
+	}
+}
+
+// hashAuditRecord hashes rec with an empty Hash field.
+func hashAuditRecord(rec AuditRecord) (string, error) {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return "", gl.Errorf("failed to marshal audit record: %v", err)
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// lastAuditRecord returns the seq and hash of the last complete record in path.
+func lastAuditRecord(path string) (uint64, string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, "", nil
+	}
+	if err != nil {
+		return 0, "", gl.Errorf("failed to read audit log: %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		var rec AuditRecord
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		if err := json.Unmarshal([]byte(lines[i]), &rec); err != nil {
+			return 0, "", gl.Errorf("audit log %s ends with a malformed record: %v", path, err)
+		}
+		return rec.Seq, rec.Hash, nil
+	}
+	return 0, "", nil
+}
@@ -0,0 +1,257 @@
+package registry
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	kbxTypes "github.com/kubex-ecosystem/kbx/types"
+
+	gl "github.com/kubex-ecosystem/logz"
+)
+
+// DefaultBatchConcurrency is used when BatchOptions.Concurrency is not set.
+const DefaultBatchConcurrency = 4
+
+// BatchOptions tunes ChatBatch.
+type BatchOptions struct {
+	// Concurrency bounds the number of requests in flight.
+	Concurrency int
+	// CheckpointPath, when set, is a JSON-lines file where every finished
+	// result is appended. Successful results found there are reused on resume.
+	CheckpointPath string
+	// StopOnError cancels the remaining requests after the first failure.
+	StopOnError bool
+	// OnResult is called after each request finishes (not for resumed ones).
+	OnResult func(BatchResult)
+}
+
+// BatchResult is the outcome of one request, at the same index as the input.
+type BatchResult struct {
+	Index   int             `json:"index"`
+	Hash    string          `json:"hash"`
+	Content string          `json:"content,omitempty"`
+	Usage   *kbxTypes.Usage `json:"usage,omitempty"`
+	Error   string          `json:"error,omitempty"`
+	Resumed bool            `json:"-"`
+}
+
+// BatchReport aggregates a ChatBatch run.
+type BatchReport struct {
+	Results   []BatchResult  `json:"results"`
+	Succeeded int            `json:"succeeded"`
+	Failed    int            `json:"failed"`
+	Resumed   int            `json:"resumed"`
+	Usage     kbxTypes.Usage `json:"usage"`
+	Duration  time.Duration  `json:"duration"`
+}
+
+// ChatBatch runs every request through Chat with bounded concurrency, honoring
+// the configured per-provider rate limits. Results keep the input order.
+// Individual failures are reported in the results; the returned error is only
+// set when the batch itself cannot run (e.g. unreadable checkpoint).
+func (r *Registry) ChatBatch(ctx context.Context, reqs []kbxTypes.ChatRequest, opts BatchOptions) (*BatchReport, error) {
+	start := time.Now()
+	report := &BatchReport{Results: make([]BatchResult, len(reqs))}
+
+	hashes := make([]string, len(reqs))
+	for i, req := range reqs {
+		hashes[i] = hashChatRequest(req)
+	}
+
+	done, err := loadBatchCheckpoint(opts.CheckpointPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var checkpoint *os.File
+	if opts.CheckpointPath != "" {
+		checkpoint, err = os.OpenFile(opts.CheckpointPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, gl.Errorf("failed to open batch checkpoint: %v", err)
+		}
+		defer checkpoint.Close()
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		jobs = make(chan int)
+	)
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				res := r.runBatchItem(ctx, i, hashes[i], reqs[i])
+
+				mu.Lock()
+				report.Results[i] = res
+				if checkpoint != nil {
+					if line, err := json.Marshal(res); err == nil {
+						_, _ = checkpoint.Write(append(line, '\n'))
+					}
+				}
+				mu.Unlock()
+
+				if opts.OnResult != nil {
+					opts.OnResult(res)
+				}
+				if res.Error != "" && opts.StopOnError {
+					cancel()
+				}
+			}
+		}()
+	}
+
+	// resumed returns the checkpointed result of item i, if it can be reused.
+	resumed := func(i int) (BatchResult, bool) {
+		prev, ok := done[i]
+		if !ok || prev.Hash != hashes[i] || prev.Error != "" {
+			return BatchResult{}, false
+		}
+		prev.Resumed = true
+		return prev, true
+	}
+
+feed:
+	for i := range reqs {
+		if prev, ok := resumed(i); ok {
+			report.Results[i] = prev
+			continue
+		}
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			// Items not fed yet are cancelled, except those done in an earlier run.
+			mu.Lock()
+			for j := i; j < len(reqs); j++ {
+				if prev, ok := resumed(j); ok {
+					report.Results[j] = prev
+				} else if report.Results[j].Hash == "" {
+					report.Results[j] = BatchResult{Index: j, Hash: hashes[j], Error: ctx.Err().Error()}
+				}
+			}
+			mu.Unlock()
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, res := range report.Results {
+		switch {
+		case res.Error != "":
+			report.Failed++
+		case res.Resumed:
+			report.Resumed++
+			report.Succeeded++
+		default:
+			report.Succeeded++
+		}
+		if res.Usage != nil {
+			report.Usage.Prompt += res.Usage.Prompt
+			report.Usage.Completion += res.Usage.Completion
+			report.Usage.Tokens += res.Usage.Tokens
+			report.Usage.CostUSD += res.Usage.CostUSD
+			report.Usage.Ms += res.Usage.Ms
+		}
+	}
+	report.Duration = time.Since(start)
+
+	gl.Infof("Batch finished: %d ok (%d resumed), %d failed, %d tokens, $%.4f in %v",
+		report.Succeeded, report.Resumed, report.Failed, report.Usage.Tokens, report.Usage.CostUSD, report.Duration)
+	return report, nil
+}
+
+func (r *Registry) runBatchItem(ctx context.Context, index int, hash string, req kbxTypes.ChatRequest) BatchResult {
+	res := BatchResult{Index: index, Hash: hash}
+
+	if err := r.WaitRateLimit(ctx, req.Provider); err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	stream, err := r.Chat(ctx, req)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	var content strings.Builder
+	for chunk := range stream {
+		if chunk.IsError() && res.Error == "" {
+			res.Error = chunk.Error
+		}
+		content.WriteString(chunk.Content)
+		if chunk.Usage != nil {
+			res.Usage = chunk.Usage
+		}
+	}
+	res.Content = content.String()
+	return res
+}
+
+// hashChatRequest identifies a request in checkpoints and audit records. It
+// covers every field that can change the answer; Headers, Stream and Timeouts
+// only affect the transport and are left out.
+func hashChatRequest(req kbxTypes.ChatRequest) string {
+	req.Headers = nil
+	req.Stream = false
+	req.Timeouts = nil
+	req.Provider = normalizeProviderName(req.Provider)
+	req.Model = strings.TrimSpace(req.Model)
+	payload, err := json.Marshal(req)
+	if err != nil {
+		// Meta holds a value JSON cannot encode; hash without it.
+		req.Meta = nil
+		payload, _ = json.Marshal(req)
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+func loadBatchCheckpoint(path string) (map[int]BatchResult, error) {
+	done := map[int]BatchResult{}
+	if path == "" {
+		return done, nil
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, gl.Errorf("failed to read batch checkpoint: %v", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var res BatchResult
+		if err := json.Unmarshal(scanner.Bytes(), &res); err != nil {
+			continue // partially written line from an interrupted run
+		}
+		// Later lines win, so a retried success replaces an earlier failure.
+		done[res.Index] = res
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, gl.Errorf("failed to read batch checkpoint: %v", err)
+	}
+	return done, nil
+}
@@ -0,0 +1,212 @@
+package registry
+
+import (
+	"context"
+	_ "embed"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	kbxTypes "github.com/kubex-ecosystem/kbx/types"
+	gl "github.com/kubex-ecosystem/logz"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed capabilities.yaml
+var builtinCapabilities []byte
+
+// CapabilitiesPathEnv overrides LLMConfig.CapabilitiesFile.
+const CapabilitiesPathEnv = "KUBEX_LLM_CAPABILITIES_PATH"
+
+// capabilityTable maps provider type -> model name or path.Match pattern -> descriptor.
+type capabilityTable map[string]map[string]kbxTypes.ModelCapabilities
+
+// capabilityCatalog layers local overrides on top of the built-in descriptors.
+// Vendor metadata, when an adapter has it, sits between the two.
+type capabilityCatalog struct {
+	mu        sync.RWMutex
+	builtin   capabilityTable
+	overrides capabilityTable
+}
+
+var capabilities = newCapabilityCatalog()
+
+func newCapabilityCatalog() *capabilityCatalog {
+	c := &capabilityCatalog{overrides: capabilityTable{}}
+	if err := yaml.Unmarshal(builtinCapabilities, &c.builtin); err != nil {
+		panic("invalid built-in capabilities.yaml: " + err.Error())
+	}
+	return c
+}
+
+// LoadCapabilityOverrides reads a YAML file with the same layout as the
+// built-in catalog (provider type -> model -> descriptor). Matching entries
+// replace built-in and vendor descriptors.
+func LoadCapabilityOverrides(file string) error {
+	data, err := os.ReadFile(os.ExpandEnv(file))
+	if err != nil {
+		return gl.Errorf("failed to read capabilities file: %v", err)
+	}
+	table := capabilityTable{}
+	if err := yaml.Unmarshal(data, &table); err != nil {
+		return gl.Errorf("failed to parse capabilities file '%s': %v", file, err)
+	}
+
+	capabilities.mu.Lock()
+	defer capabilities.mu.Unlock()
+	for providerType, models := range table {
+		providerType = normalizeProviderName(providerType)
+		if capabilities.overrides[providerType] == nil {
+			capabilities.overrides[providerType] = map[string]kbxTypes.ModelCapabilities{}
+		}
+		for model, caps := range models {
+			capabilities.overrides[providerType][model] = caps
+		}
+	}
+	return nil
+}
+
+// lookupCapabilities resolves a model's descriptor: override, else vendor, else built-in.
+func lookupCapabilities(providerType, model string, vendor *kbxTypes.ModelCapabilities) (kbxTypes.ModelCapabilities, bool) {
+	capabilities.mu.RLock()
+	defer capabilities.mu.RUnlock()
+
+	providerType = normalizeProviderName(providerType)
+	if caps, ok := matchCapabilities(capabilities.overrides[providerType], model); ok {
+		return caps, true
+	}
+	if vendor != nil {
+		return *vendor, true
+	}
+	return matchCapabilities(capabilities.builtin[providerType], model)
+}
+
+// catalogModels lists the concrete (non-pattern) models known for a provider type.
+func catalogModels(providerType string) []string {
+	capabilities.mu.RLock()
+	defer capabilities.mu.RUnlock()
+
+	providerType = normalizeProviderName(providerType)
+	seen := map[string]struct{}{}
+	for _, table := range []capabilityTable{capabilities.overrides, capabilities.builtin} {
+		for model := range table[providerType] {
+			if !strings.ContainsAny(model, "*?[") {
+				seen[model] = struct{}{}
+			}
+		}
+	}
+	models := make([]string, 0, len(seen))
+	for model := range seen {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	return models
+}
+
+func matchCapabilities(models map[string]kbxTypes.ModelCapabilities, model string) (kbxTypes.ModelCapabilities, bool) {
+	if caps, ok := models[model]; ok {
+		return caps, true
+	}
+	best := ""
+	for pattern := range models {
+		if ok, _ := path.Match(pattern, model); ok && len(pattern) > len(best) {
+			best = pattern
+		}
+	}
+	if best == "" {
+		return kbxTypes.ModelCapabilities{}, false
+	}
+	return models[best], true
+}
+
+// modelInfo builds the ModelInfo map shared by the adapters.
+func modelInfo(name, providerType, model string, vendor *kbxTypes.ModelCapabilities) map[string]any {
+	info := map[string]any{
+		"name":        model,
+		"provider":    name,
+		"type":        providerType,
+		"description": "Default model for " + name,
+	}
+	if caps, ok := lookupCapabilities(providerType, model, vendor); ok {
+		info["capabilities"] = caps
+		info["context_window"] = caps.ContextWindow
+		info["max_tokens"] = caps.MaxOutputTokens
+	}
+	return info
+}
+
+// -------------------------------- CAPABILITY ROUTING --------------------------------
+
+// ModelCapabilities returns the descriptor for model on the named provider
+// (its default model when empty).
+func (r *Registry) ModelCapabilities(ctx context.Context, name, model string) (kbxTypes.ModelCapabilities, bool) {
+	p := r.ResolveProvider(name)
+	if p == nil {
+		return kbxTypes.ModelCapabilities{}, false
+	}
+	info, err := p.ModelInfo(ctx)
+	if err == nil {
+		if current, _ := info["name"].(string); model == "" || model == current {
+			return kbxTypes.CapabilitiesFromInfo(info)
+		}
+	}
+	return lookupCapabilities(normalizeProviderType(name, r.GetProviderConfig(name)), model, nil)
+}
+
+// routeByCapabilities picks the provider/model for a request carrying
+// Requires. The requested provider and model are kept when they qualify;
+// otherwise the requested provider's other known models are tried, then the
+// other loaded providers in name order. Providers and models the caller's
+// tenant does not allow are skipped.
+func (r *Registry) routeByCapabilities(ctx context.Context, req kbxTypes.ChatRequest) (string, string, error) {
+	need := *req.Requires
+
+	names := r.ListProviders()
+	requested := normalizeProviderName(req.Provider)
+	if requested != "" {
+		ordered := []string{requested}
+		for _, n := range names {
+			if n != requested {
+				ordered = append(ordered, n)
+			}
+		}
+		names = ordered
+	}
+
+	tc := r.tenantConfig(r.tenantID(req))
+	for _, name := range names {
+		if !tenantAllows(tc, name) {
+			continue
+		}
+		p := r.ResolveProvider(name)
+		if p == nil {
+			continue
+		}
+		candidates := []string{""}
+		if name == requested && req.Model != "" {
+			candidates = []string{req.Model}
+		}
+		candidates = append(candidates, catalogModels(normalizeProviderType(name, r.GetProviderConfig(name)))...)
+
+		var tpc *kbxTypes.LLMTenantProviderConfig
+		if tc != nil {
+			tpc = tc.Providers[name]
+		}
+		for _, model := range candidates {
+			if _, allowed := r.tenantAllowsModel(tpc, name, model); !allowed {
+				continue
+			}
+			caps, ok := r.ModelCapabilities(ctx, name, model)
+			if !ok || !caps.Satisfies(need) {
+				continue
+			}
+			if name != requested || (req.Model != "" && model != req.Model) {
+				gl.Debugf("Routing request for %s/%s to %s/%s to satisfy required capabilities", req.Provider, req.Model, name, model)
+			}
+			return name, model, nil
+		}
+	}
+	return "", "", gl.Errorf("%w: no loaded provider/model satisfies the required capabilities %+v", ErrProviderNotFound, need)
+}
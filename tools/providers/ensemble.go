@@ -0,0 +1,301 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubex-ecosystem/kbx/tools/prompts"
+	kbxTypes "github.com/kubex-ecosystem/kbx/types"
+
+	gl "github.com/kubex-ecosystem/logz"
+)
+
+// Ensemble strategies.
+const (
+	// EnsembleFirstSuccess returns the first member answer without error and
+	// cancels the rest.
+	EnsembleFirstSuccess = "first_success"
+	// EnsembleMajority returns the answer most members agree on, comparing
+	// JSON answers structurally and text answers case/space-insensitively.
+	EnsembleMajority = "majority"
+	// EnsembleJudge asks a judge model to merge all member answers.
+	EnsembleJudge = "judge"
+)
+
+// EnsembleJudgePrompt is the prompt library template used by EnsembleJudge.
+const EnsembleJudgePrompt = "ensemble-judge"
+
+// EnsembleMember is one provider/model asked by an ensemble request.
+type EnsembleMember struct {
+	Provider string
+	Model    string
+}
+
+// EnsembleOptions configures ChatEnsemble.
+type EnsembleOptions struct {
+	Members  []EnsembleMember
+	Strategy string
+	// Judge is the model merging answers for EnsembleJudge.
+	Judge EnsembleMember
+	// JudgePromptVersion selects the judge template version (latest when empty).
+	JudgePromptVersion string
+	// Normalize overrides the answer key used by EnsembleMajority.
+	Normalize func(string) string
+}
+
+// ChatEnsemble fans req out to every member concurrently and combines the
+// answers with the selected strategy. The stream carries the combined answer
+// followed by a Done chunk whose usage sums every call made, judge included.
+// Nothing is streamed before every member has answered: EnsembleFirstSuccess
+// and EnsembleMajority then send the chosen answer in one chunk, while
+// EnsembleJudge forwards the judge's chunks as they arrive.
+func (r *Registry) ChatEnsemble(ctx context.Context, req kbxTypes.ChatRequest, opts EnsembleOptions) (<-chan kbxTypes.ChatChunk, error) {
+	if len(opts.Members) == 0 {
+		return nil, gl.Errorf("ensemble requires at least one member")
+	}
+	strategy := strings.ToLower(strings.TrimSpace(opts.Strategy))
+	switch strategy {
+	case "":
+		strategy = EnsembleFirstSuccess
+	case EnsembleFirstSuccess, EnsembleMajority:
+	case EnsembleJudge:
+		if opts.Judge.Provider == "" {
+			return nil, gl.Errorf("ensemble strategy '%s' requires a judge provider", EnsembleJudge)
+		}
+	default:
+		return nil, gl.Errorf("unknown ensemble strategy '%s'", opts.Strategy)
+	}
+
+	out := make(chan kbxTypes.ChatChunk, 2)
+	go func() {
+		defer close(out)
+		start := time.Now()
+
+		arms := r.fanOut(ctx, req, opts.Members, strategy == EnsembleFirstSuccess)
+		usage := &kbxTypes.Usage{Provider: "ensemble", Model: strategy}
+		for _, arm := range arms {
+			addUsage(usage, arm.Usage)
+		}
+
+		var (
+			answer string
+			err    error
+		)
+		switch strategy {
+		case EnsembleFirstSuccess:
+			answer, err = firstSuccess(arms)
+		case EnsembleMajority:
+			answer, err = majorityVote(arms, opts.Normalize)
+		case EnsembleJudge:
+			err = r.streamJudge(ctx, req, arms, opts, usage, out)
+		}
+		usage.Ms = time.Since(start).Milliseconds()
+
+		if err != nil {
+			select {
+			case out <- kbxTypes.ChatChunk{Error: err.Error(), Done: true, Usage: usage}:
+			case <-ctx.Done():
+			}
+			return
+		}
+		if answer != "" {
+			select {
+			case out <- kbxTypes.ChatChunk{Content: answer}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		select {
+		case out <- kbxTypes.ChatChunk{Done: true, Usage: usage}:
+		case <-ctx.Done():
+		}
+	}()
+	return out, nil
+}
+
+// fanOut runs req against every member. With firstWins, the remaining members
+// are cancelled once one succeeds; their partial usage is still reported.
+func (r *Registry) fanOut(ctx context.Context, req kbxTypes.ChatRequest, members []EnsembleMember, firstWins bool) []ArmResult {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	arms := make([]ArmResult, len(members))
+	var wg sync.WaitGroup
+	for i, m := range members {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			memberReq := req
+			memberReq.Provider = m.Provider
+			memberReq.Model = m.Model
+			memberReq.Messages = append([]kbxTypes.Message(nil), req.Messages...)
+			arms[i] = collectArm(ctx, r.Chat, memberReq)
+			if firstWins && arms[i].Error == "" {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	return arms
+}
+
+func firstSuccess(arms []ArmResult) (string, error) {
+	var fastest *ArmResult
+	for i := range arms {
+		if arms[i].Error != "" {
+			continue
+		}
+		if fastest == nil || arms[i].LatencyMs < fastest.LatencyMs {
+			fastest = &arms[i]
+		}
+	}
+	if fastest == nil {
+		return "", ensembleFailure(arms)
+	}
+	return fastest.Content, nil
+}
+
+func majorityVote(arms []ArmResult, normalize func(string) string) (string, error) {
+	if normalize == nil {
+		normalize = normalizeAnswer
+	}
+	type tally struct {
+		answer string
+		votes  int
+	}
+	var order []string
+	votes := map[string]*tally{}
+	for _, arm := range arms {
+		if arm.Error != "" {
+			continue
+		}
+		key := normalize(arm.Content)
+		if t, ok := votes[key]; ok {
+			t.votes++
+			continue
+		}
+		votes[key] = &tally{answer: arm.Content, votes: 1}
+		order = append(order, key)
+	}
+	if len(order) == 0 {
+		return "", ensembleFailure(arms)
+	}
+
+	// Ties go to the answer seen first, i.e. from the earliest listed member.
+	best := votes[order[0]]
+	for _, key := range order[1:] {
+		if votes[key].votes > best.votes {
+			best = votes[key]
+		}
+	}
+	if best.votes*2 <= len(arms) {
+		gl.Warnf("Ensemble majority not reached: best answer has %d of %d votes", best.votes, len(arms))
+	}
+	return best.answer, nil
+}
+
+// streamJudge asks the judge to merge the member answers and forwards its
+// content and reasoning to out as they arrive, adding its usage to usage.
+func (r *Registry) streamJudge(ctx context.Context, req kbxTypes.ChatRequest, arms []ArmResult, opts EnsembleOptions, usage *kbxTypes.Usage, out chan<- kbxTypes.ChatChunk) error {
+	answers := make([]string, 0, len(arms))
+	for _, arm := range arms {
+		if arm.Error == "" && strings.TrimSpace(arm.Content) != "" {
+			answers = append(answers, arm.Content)
+		}
+	}
+	if len(answers) == 0 {
+		return ensembleFailure(arms)
+	}
+
+	question := ""
+	if n := len(req.Messages); n > 0 {
+		question = req.Messages[n-1].Content
+	}
+	messages, err := prompts.Default().Render(EnsembleJudgePrompt, opts.JudgePromptVersion, map[string]any{
+		"question": question,
+		"answers":  answers,
+	})
+	if err != nil {
+		return err
+	}
+
+	judgeReq := req
+	judgeReq.Provider = opts.Judge.Provider
+	judgeReq.Model = opts.Judge.Model
+	judgeReq.Messages = messages
+	stream, err := r.Chat(ctx, judgeReq)
+	if err != nil {
+		return gl.Errorf("ensemble judge '%s' failed: %v", opts.Judge.Provider, err)
+	}
+
+	var judgeUsage *kbxTypes.Usage
+	defer func() { addUsage(usage, judgeUsage) }()
+	for c := range stream {
+		if c.Usage != nil {
+			judgeUsage = c.Usage
+		}
+		if c.IsError() {
+			// Drain so the judge's provider goroutine can finish.
+			for range stream {
+			}
+			return gl.Errorf("ensemble judge '%s' failed: %s", opts.Judge.Provider, c.Error)
+		}
+		if !c.HasContent() && !c.HasReasoning() {
+			continue
+		}
+		select {
+		case out <- kbxTypes.ChatChunk{Content: c.Content, Reasoning: c.Reasoning}:
+		case <-ctx.Done():
+			for range stream {
+			}
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// normalizeAnswer compacts JSON answers so key order and spacing do not split
+// votes, and lowercases/collapses whitespace for plain text.
+func normalizeAnswer(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "```json")
+	s = strings.Trim(s, "`")
+	s = strings.TrimSpace(s)
+
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err == nil {
+		// encoding/json sorts map keys, giving a canonical form.
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if enc.Encode(v) == nil {
+			return strings.TrimSpace(buf.String())
+		}
+	}
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+func ensembleFailure(arms []ArmResult) error {
+	errs := make([]string, 0, len(arms))
+	for _, arm := range arms {
+		if arm.Error != "" {
+			errs = append(errs, arm.Provider+": "+arm.Error)
+		}
+	}
+	return gl.Errorf("all %d ensemble members failed: %s", len(arms), strings.Join(errs, "; "))
+}
+
+func addUsage(total, u *kbxTypes.Usage) {
+	if u == nil {
+		return
+	}
+	total.Prompt += u.Prompt
+	total.Completion += u.Completion
+	total.Reasoning += u.Reasoning
+	total.Tokens += u.Tokens
+	total.CostUSD += u.CostUSD
+}
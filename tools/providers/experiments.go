@@ -0,0 +1,366 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"maps"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	kbxTypes "github.com/kubex-ecosystem/kbx/types"
+
+	gl "github.com/kubex-ecosystem/logz"
+)
+
+// MetaTenantID is the ChatRequest.Meta key carrying the caller's tenant.
+const MetaTenantID = "tenantId"
+
+// DefaultShadowTimeout bounds a shadow call once the caller no longer waits for it.
+const DefaultShadowTimeout = 2 * time.Minute
+
+// Comparison record kinds.
+const (
+	ComparisonShadow = "shadow"
+	ComparisonAB     = "ab"
+)
+
+// ArmResult is what one provider/model produced for a request.
+type ArmResult struct {
+	Variant   string          `json:"variant,omitempty"`
+	Provider  string          `json:"provider"`
+	Model     string          `json:"model,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	LatencyMs int64           `json:"latency_ms"`
+	TTFTMs    int64           `json:"ttft_ms,omitempty"`
+	Usage     *kbxTypes.Usage `json:"usage,omitempty"`
+}
+
+// ComparisonRecord is written to an ExperimentSink for every sampled request.
+// Shadow records carry both arms; A/B records carry only the served arm.
+type ComparisonRecord struct {
+	ID         string     `json:"id"`
+	Kind       string     `json:"kind"`
+	Experiment string     `json:"experiment"`
+	Tenant     string     `json:"tenant,omitempty"`
+	Timestamp  time.Time  `json:"timestamp"`
+	Primary    ArmResult  `json:"primary"`
+	Shadow     *ArmResult `json:"shadow,omitempty"`
+}
+
+// ExperimentSink receives comparison records. Implementations must be safe for
+// concurrent use; errors are logged and never reach the caller.
+type ExperimentSink interface {
+	Record(ctx context.Context, rec ComparisonRecord) error
+}
+
+// SinkFunc adapts a function to ExperimentSink.
+type SinkFunc func(ctx context.Context, rec ComparisonRecord) error
+
+func (f SinkFunc) Record(ctx context.Context, rec ComparisonRecord) error { return f(ctx, rec) }
+
+// JSONLSink appends records as JSON lines to a file.
+type JSONLSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewJSONLSink opens (or creates) path for appending.
+func NewJSONLSink(path string) (*JSONLSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, gl.Errorf("failed to open experiment sink: %v", err)
+	}
+	return &JSONLSink{file: f}, nil
+}
+
+func (s *JSONLSink) Record(_ context.Context, rec ComparisonRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+func (s *JSONLSink) Close() error { return s.file.Close() }
+
+// -------------------------------- SHADOW TRAFFIC --------------------------------
+
+// ShadowConfig mirrors a share of requests to another provider/model.
+type ShadowConfig struct {
+	Name     string
+	Provider string
+	Model    string
+	// Percent of requests mirrored, 0-100.
+	Percent float64
+	// Timeout bounds the shadow call (DefaultShadowTimeout when zero).
+	Timeout time.Duration
+}
+
+// ShadowMiddleware mirrors cfg.Percent of requests to the shadow provider. The
+// caller only ever sees the primary stream; the shadow runs on a detached
+// context and both arms are written to sink once they finish.
+//
+// The shadow is dispatched straight to the shared instance of cfg.Provider, so
+// it never touches the caller tenant's rate limit, budget or circuit breakers.
+// It is skipped when the tenant has not enabled that provider or model, or
+// uses its own key for it.
+// Middlewares registered after this one do not see it either: register
+// RedactionMiddleware before it.
+func (r *Registry) ShadowMiddleware(cfg ShadowConfig, sink ExperimentSink) Middleware {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultShadowTimeout
+	}
+	return func(next ChatHandler) ChatHandler {
+		return func(ctx context.Context, req kbxTypes.ChatRequest) (<-chan kbxTypes.ChatChunk, error) {
+			if sink == nil || cfg.Percent <= 0 || rand.Float64()*100 >= cfg.Percent {
+				return next(ctx, req)
+			}
+
+			rec := ComparisonRecord{
+				ID:         uuid.NewString(),
+				Kind:       ComparisonShadow,
+				Experiment: cfg.Name,
+				Tenant:     tenantOf(req),
+				Timestamp:  time.Now().UTC(),
+			}
+
+			// Later stages may rewrite messages or meta; keep the arms independent.
+			shadowReq := req
+			shadowReq.Provider = cfg.Provider
+			shadowReq.Model = cfg.Model
+			shadowReq.Messages = append([]kbxTypes.Message(nil), req.Messages...)
+			shadowReq.Meta = maps.Clone(req.Meta)
+			shadowReq.Requires = nil
+			if tenant := r.tenantID(req); !r.tenantMayShadow(tenant, shadowReq) {
+				gl.Debugf("Skipping shadow '%s' to provider '%s': not enabled on the shared key for tenant '%s'", cfg.Name, cfg.Provider, tenant)
+				return next(ctx, req)
+			}
+			shadowDone := make(chan ArmResult, 1)
+			go func() {
+				sctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+				defer cancel()
+				shadowDone <- collectArm(sctx, r.dispatchShadow, shadowReq)
+			}()
+
+			primary, err := next(ctx, req)
+			if err != nil {
+				go func() {
+					shadow := <-shadowDone
+					rec.Primary = ArmResult{Provider: req.Provider, Model: req.Model, Error: err.Error()}
+					rec.Shadow = &shadow
+					recordComparison(sink, rec)
+				}()
+				return nil, err
+			}
+
+			return observeArm(ctx, primary, req, func(arm ArmResult) {
+				go func() {
+					shadow := <-shadowDone
+					rec.Primary = arm
+					rec.Shadow = &shadow
+					recordComparison(sink, rec)
+				}()
+			}), nil
+		}
+	}
+}
+
+// dispatchShadow sends a shadow request to the shared provider instance,
+// bounded by the provider's timeouts and outside any tenant accounting.
+func (r *Registry) dispatchShadow(ctx context.Context, req kbxTypes.ChatRequest) (<-chan kbxTypes.ChatChunk, error) {
+	p := r.ResolveProvider(req.Provider)
+	if p == nil {
+		return nil, gl.Errorf("%w: '%s'", ErrProviderNotFound, req.Provider)
+	}
+	return watchTimeouts(ctx, p, req, r.resolveTimeouts(req.Provider, req))
+}
+
+// -------------------------------- A/B SPLITS --------------------------------
+
+// ABVariant is one arm of an A/B split.
+type ABVariant struct {
+	Name     string
+	Provider string
+	Model    string
+	Weight   int
+}
+
+// ABConfig splits traffic between weighted variants. A tenant always lands on
+// the same variant; requests without a tenant are assigned at random.
+type ABConfig struct {
+	Name     string
+	Variants []ABVariant
+	// Tenants overrides the variant weights for specific tenants.
+	Tenants map[string][]ABVariant
+}
+
+// Assign returns the variant served to tenant, or false if there are none.
+func (c ABConfig) Assign(tenant string) (ABVariant, bool) {
+	variants := c.Variants
+	if override, ok := c.Tenants[tenant]; ok && tenant != "" {
+		variants = override
+	}
+	total := 0
+	for _, v := range variants {
+		if v.Weight > 0 {
+			total += v.Weight
+		}
+	}
+	if total == 0 {
+		return ABVariant{}, false
+	}
+
+	var bucket int
+	if tenant == "" {
+		bucket = rand.IntN(total)
+	} else {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(c.Name + "\x00" + tenant))
+		bucket = int(h.Sum32() % uint32(total))
+	}
+	for _, v := range variants {
+		if v.Weight <= 0 {
+			continue
+		}
+		if bucket < v.Weight {
+			return v, true
+		}
+		bucket -= v.Weight
+	}
+	return ABVariant{}, false
+}
+
+// ABMiddleware rewrites the request's provider/model to the tenant's variant
+// and records the served arm to sink.
+func ABMiddleware(cfg ABConfig, sink ExperimentSink) Middleware {
+	return func(next ChatHandler) ChatHandler {
+		return func(ctx context.Context, req kbxTypes.ChatRequest) (<-chan kbxTypes.ChatChunk, error) {
+			tenant := tenantOf(req)
+			variant, ok := cfg.Assign(tenant)
+			if !ok {
+				return next(ctx, req)
+			}
+			req.Provider = variant.Provider
+			if variant.Model != "" {
+				req.Model = variant.Model
+			}
+
+			rec := ComparisonRecord{
+				ID:         uuid.NewString(),
+				Kind:       ComparisonAB,
+				Experiment: cfg.Name,
+				Tenant:     tenant,
+				Timestamp:  time.Now().UTC(),
+			}
+			stream, err := next(ctx, req)
+			if err != nil {
+				if sink != nil {
+					rec.Primary = ArmResult{Variant: variant.Name, Provider: req.Provider, Model: req.Model, Error: err.Error()}
+					go recordComparison(sink, rec)
+				}
+				return nil, err
+			}
+			if sink == nil {
+				return stream, nil
+			}
+			return observeArm(ctx, stream, req, func(arm ArmResult) {
+				arm.Variant = variant.Name
+				rec.Primary = arm
+				go recordComparison(sink, rec)
+			}), nil
+		}
+	}
+}
+
+// -------------------------------- HELPERS --------------------------------
+
+// tenantOf returns the tenant from ChatRequest.Meta, if any.
+func tenantOf(req kbxTypes.ChatRequest) string {
+	tenant, _ := req.Meta[MetaTenantID].(string)
+	return strings.TrimSpace(tenant)
+}
+
+// observeArm passes the stream through unchanged and calls done with the
+// accumulated arm result once it ends.
+func observeArm(ctx context.Context, in <-chan kbxTypes.ChatChunk, req kbxTypes.ChatRequest, done func(ArmResult)) <-chan kbxTypes.ChatChunk {
+	out := make(chan kbxTypes.ChatChunk, cap(in))
+	go func() {
+		defer close(out)
+		start := time.Now()
+		arm := ArmResult{Provider: req.Provider, Model: req.Model}
+		var content strings.Builder
+		defer func() {
+			arm.Content = content.String()
+			arm.LatencyMs = time.Since(start).Milliseconds()
+			done(arm)
+		}()
+
+		for c := range in {
+			if c.HasContent() && arm.TTFTMs == 0 {
+				arm.TTFTMs = time.Since(start).Milliseconds()
+			}
+			content.WriteString(c.Content)
+			if c.Usage != nil {
+				arm.Usage = c.Usage
+			}
+			if c.IsError() && arm.Error == "" {
+				arm.Error = c.Error
+			}
+			select {
+			case out <- c:
+			case <-ctx.Done():
+				for range in {
+				}
+				if arm.Error == "" {
+					arm.Error = ctx.Err().Error()
+				}
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// collectArm runs req to completion and returns the accumulated result.
+func collectArm(ctx context.Context, h ChatHandler, req kbxTypes.ChatRequest) ArmResult {
+	start := time.Now()
+	arm := ArmResult{Provider: req.Provider, Model: req.Model}
+	stream, err := h(ctx, req)
+	if err != nil {
+		arm.Error = err.Error()
+		arm.LatencyMs = time.Since(start).Milliseconds()
+		return arm
+	}
+	var content strings.Builder
+	for c := range stream {
+		if c.HasContent() && arm.TTFTMs == 0 {
+			arm.TTFTMs = time.Since(start).Milliseconds()
+		}
+		content.WriteString(c.Content)
+		if c.Usage != nil {
+			arm.Usage = c.Usage
+		}
+		if c.IsError() && arm.Error == "" {
+			arm.Error = c.Error
+		}
+	}
+	arm.Content = content.String()
+	arm.LatencyMs = time.Since(start).Milliseconds()
+	return arm
+}
+
+func recordComparison(sink ExperimentSink, rec ComparisonRecord) {
+	if err := sink.Record(context.Background(), rec); err != nil {
+		gl.Warnf("Failed to record %s comparison for experiment '%s': %v", rec.Kind, rec.Experiment, err)
+	}
+}
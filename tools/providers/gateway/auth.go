@@ -0,0 +1,172 @@
+package gateway
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	errMissingCredentials = errors.New("missing bearer token")
+	errInvalidCredentials = errors.New("invalid API key or token")
+	errTenantMismatch     = errors.New("tenant header does not match the credential's tenant")
+)
+
+// authenticate enforces LLMSecurityConfig.APIKeys, TenantAPIKeys and
+// JWTSecret and returns the caller's tenant. When none is configured the
+// gateway is open, matching the registry's permissive defaults. The tenant is
+// bound to the credential: a key listed in TenantAPIKeys acts as its tenant, a
+// JWT as its tenant_id claim, anything else as the default tenant.
+func (g *Gateway) authenticate(r *http.Request) (string, error) {
+	if len(g.security.APIKeys) == 0 && len(g.security.TenantAPIKeys) == 0 && g.security.JWTSecret == "" {
+		return g.checkTenantHeader(r, "")
+	}
+
+	token := bearerToken(r)
+	if token == "" {
+		return "", errMissingCredentials
+	}
+
+	for tenant, keys := range g.security.TenantAPIKeys {
+		if keyMatches(token, keys) {
+			return g.checkTenantHeader(r, tenant)
+		}
+	}
+	if keyMatches(token, g.security.APIKeys) {
+		return g.checkTenantHeader(r, "")
+	}
+
+	if g.security.JWTSecret != "" && strings.Count(token, ".") == 2 {
+		tenant, err := verifyHS256(token, []byte(g.security.JWTSecret), time.Now())
+		if err != nil {
+			return "", err
+		}
+		return g.checkTenantHeader(r, tenant)
+	}
+
+	return "", errInvalidCredentials
+}
+
+// keyMatches reports whether token is one of keys, in constant time per key.
+func keyMatches(token string, keys []string) bool {
+	for _, key := range keys {
+		if key != "" && subtle.ConstantTimeCompare([]byte(token), []byte(key)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTenantHeader rejects a TenantHeader naming another tenant than the one
+// bound to the caller's credential (the configured default tenant when none);
+// the header never selects a tenant.
+func (g *Gateway) checkTenantHeader(r *http.Request, tenant string) (string, error) {
+	want := strings.TrimSpace(r.Header.Get(TenantHeader))
+	if want == "" {
+		return tenant, nil
+	}
+	bound := tenant
+	if bound == "" {
+		bound = strings.TrimSpace(g.reg.Config().Development.Defaults.TenantID)
+	}
+	if want != bound {
+		return "", errTenantMismatch
+	}
+	return tenant, nil
+}
+
+// applyCORS writes CORS headers for allowed origins. It reports false when the
+// request carries an Origin that is not in LLMSecurityConfig.AllowedOrigins.
+func (g *Gateway) applyCORS(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if len(g.security.AllowedOrigins) == 0 {
+		return r.Method != http.MethodOptions
+	}
+
+	allowed := false
+	for _, o := range g.security.AllowedOrigins {
+		if o == "*" || strings.EqualFold(strings.TrimRight(o, "/"), strings.TrimRight(origin, "/")) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return false
+	}
+
+	h := w.Header()
+	h.Set("Access-Control-Allow-Origin", origin)
+	h.Add("Vary", "Origin")
+	h.Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+	h.Set("Access-Control-Allow-Headers", "Authorization, Content-Type, "+ProviderHeader+", "+TenantHeader)
+	h.Set("Access-Control-Max-Age", "600")
+	return true
+}
+
+func bearerToken(r *http.Request) string {
+	auth := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	// Anthropic-style SDKs send the key in x-api-key.
+	return strings.TrimSpace(r.Header.Get("X-Api-Key"))
+}
+
+// verifyHS256 validates a compact JWT signed with HMAC-SHA256 and its exp/nbf
+// claims, and returns its tenant_id claim.
+func verifyHS256(token string, secret []byte, now time.Time) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errInvalidCredentials
+	}
+
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", errInvalidCredentials
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(rawHeader, &header); err != nil || header.Alg != "HS256" {
+		return "", errInvalidCredentials
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errInvalidCredentials
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return "", errInvalidCredentials
+	}
+
+	rawClaims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", errInvalidCredentials
+	}
+	var claims struct {
+		Exp      *int64 `json:"exp"`
+		Nbf      *int64 `json:"nbf"`
+		TenantID string `json:"tenant_id"`
+	}
+	if err := json.Unmarshal(rawClaims, &claims); err != nil {
+		return "", errInvalidCredentials
+	}
+	if claims.Exp != nil && now.Unix() >= *claims.Exp {
+		return "", errors.New("token expired")
+	}
+	if claims.Nbf != nil && now.Unix() < *claims.Nbf {
+		return "", errors.New("token not yet valid")
+	}
+	return strings.TrimSpace(claims.TenantID), nil
+}
@@ -0,0 +1,401 @@
+// Package gateway exposes a provider Registry through an OpenAI-compatible HTTP API.
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	kbxMod "github.com/kubex-ecosystem/kbx/internal/module/kbx"
+	registry "github.com/kubex-ecosystem/kbx/tools/providers"
+	"github.com/kubex-ecosystem/kbx/tools/providers/sse"
+	"github.com/kubex-ecosystem/kbx/tools/security/bitflags"
+	kbxTypes "github.com/kubex-ecosystem/kbx/types"
+
+	gl "github.com/kubex-ecosystem/logz"
+)
+
+const (
+	// ProviderHeader selects the provider when the model name carries no "provider/" prefix.
+	ProviderHeader = "X-KBX-Provider"
+	// TenantHeader may state the caller's tenant (see registry.MetaTenantID).
+	// The tenant comes from the credential; a header naming another one is rejected.
+	TenantHeader = "X-KBX-Tenant"
+
+	maxRequestBodyBytes = 4 << 20
+)
+
+// Gateway is an http.Handler serving /v1/chat/completions, /v1/models and /healthz
+// on top of a Registry, so existing OpenAI SDKs can talk to every configured provider.
+type Gateway struct {
+	reg             *registry.Registry
+	security        kbxTypes.LLMSecurityConfig
+	defaultProvider string
+	flags           bitflags.SecFlag
+	// forwardHeaders lists the client headers passed on to the vendor.
+	forwardHeaders []string
+	mux            *http.ServeMux
+}
+
+// tenantCtxKey carries the tenant resolved by authenticate to the handlers.
+type tenantCtxKey struct{}
+
+// -------------------------------- GATEWAY CONSTRUCTORS --------------------------------
+
+func NewGateway(reg *registry.Registry) *Gateway {
+	if reg == nil {
+		reg = registry.NewRegistry(nil)
+	}
+	g := &Gateway{
+		reg:             reg,
+		security:        reg.Config().Security,
+		defaultProvider: kbxMod.DefaultLLMProvider,
+		flags:           bitflags.SecAuth | bitflags.SecSanitize | bitflags.SecSanitizeBody,
+		mux:             http.NewServeMux(),
+	}
+	g.mux.HandleFunc("/v1/chat/completions", g.handleChatCompletions)
+	g.mux.HandleFunc("/v1/models", g.handleModels)
+	g.mux.HandleFunc("/healthz", g.handleHealth)
+	return g
+}
+
+// -------------------------------- GATEWAY GENERAL METHODS --------------------------------
+
+// WithDefaultProvider sets the provider used when neither the model nor the
+// ProviderHeader names one.
+func (g *Gateway) WithDefaultProvider(name string) *Gateway {
+	if name = strings.TrimSpace(name); name != "" {
+		g.defaultProvider = name
+	}
+	return g
+}
+
+// WithSecFlags sets the route flags used to select registry middlewares for
+// chat requests. Defaults to auth|sanitize|sanitize_body.
+func (g *Gateway) WithSecFlags(flags bitflags.SecFlag) *Gateway {
+	g.flags = flags
+	return g
+}
+
+// WithForwardHeaders lists the client request headers forwarded to the vendor.
+// No client header is forwarded by default.
+func (g *Gateway) WithForwardHeaders(names ...string) *Gateway {
+	g.forwardHeaders = names
+	return g
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !g.applyCORS(w, r) {
+		writeError(w, http.StatusForbidden, "permission_error", "origin not allowed")
+		return
+	}
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.URL.Path != "/healthz" {
+		tenant, err := g.authenticate(r)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, "authentication_error", err.Error())
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), tenantCtxKey{}, tenant))
+	}
+	g.mux.ServeHTTP(w, r)
+}
+
+// -------------------------------- HANDLERS --------------------------------
+
+func (g *Gateway) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "invalid_request_error", "method not allowed")
+		return
+	}
+
+	var body chatCompletionRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	if len(body.Messages) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "messages must not be empty")
+		return
+	}
+
+	req := g.toChatRequest(r, body)
+	stream, err := g.reg.Chat(registry.WithSecFlags(r.Context(), g.flags), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, registry.ErrProviderNotFound):
+			writeError(w, http.StatusNotFound, "invalid_request_error", err.Error())
+		case errors.Is(err, registry.ErrInvalidRequest):
+			writeError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		default:
+			writeError(w, http.StatusBadGateway, "api_error", err.Error())
+		}
+		return
+	}
+
+	id := "chatcmpl-" + uuid.NewString()
+	created := time.Now().Unix()
+	model := exposedModel(req.Provider, req.Model)
+
+	if body.Stream {
+		g.streamCompletion(r.Context(), w, stream, id, created, model)
+		return
+	}
+	g.writeCompletion(w, stream, id, created, model)
+}
+
+func (g *Gateway) handleModels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "invalid_request_error", "method not allowed")
+		return
+	}
+
+	models := modelList{Object: "list", Data: []modelEntry{}}
+	for _, name := range g.reg.ListProviders() {
+		p := g.reg.ResolveProvider(name)
+		if p == nil {
+			continue
+		}
+		ids, err := p.ListModels(r.Context())
+		if err != nil {
+			gl.Warnf("Failed to list models for provider '%s': %v", name, err)
+			continue
+		}
+		for _, id := range ids {
+			if strings.TrimSpace(id) == "" {
+				continue
+			}
+			models.Data = append(models.Data, modelEntry{
+				ID:      exposedModel(name, id),
+				Object:  "model",
+				OwnedBy: name,
+			})
+		}
+	}
+	writeJSON(w, http.StatusOK, models)
+}
+
+func (g *Gateway) handleHealth(w http.ResponseWriter, r *http.Request) {
+	status := http.StatusOK
+	report := healthReport{Status: "ok", Providers: map[string]string{}}
+	healthy := 0
+	for _, name := range g.reg.ListProviders() {
+		p := g.reg.ResolveProvider(name)
+		if p == nil {
+			continue
+		}
+		if err := p.Available(); err != nil {
+			report.Providers[name] = err.Error()
+			continue
+		}
+		report.Providers[name] = "ok"
+		healthy++
+	}
+	if healthy == 0 {
+		report.Status = "no providers available"
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
+
+// -------------------------------- PRIVATE INTERNAL METHODS --------------------------------
+
+func (g *Gateway) toChatRequest(r *http.Request, body chatCompletionRequest) kbxTypes.ChatRequest {
+	provider, model := splitModel(body.Model)
+	if provider == "" {
+		provider = strings.TrimSpace(r.Header.Get(ProviderHeader))
+	}
+	if provider == "" {
+		provider = g.defaultProvider
+	}
+
+	messages := make([]kbxTypes.Message, 0, len(body.Messages))
+	for _, m := range body.Messages {
+		msg := kbxTypes.Message{Role: m.Role, Content: m.Content, ToolCallID: m.ToolCallID, Name: m.Name}
+		for _, call := range m.ToolCalls {
+			msg.ToolCalls = append(msg.ToolCalls, fromToolCall(call))
+		}
+		messages = append(messages, msg)
+	}
+
+	var tools []kbxTypes.ToolSpec
+	for _, t := range body.Tools {
+		if t.Type != "" && t.Type != "function" {
+			continue
+		}
+		tools = append(tools, kbxTypes.ToolSpec{Name: t.Function.Name, Description: t.Function.Description, Parameters: t.Function.Parameters})
+	}
+
+	// Only the headers listed with WithForwardHeaders reach the vendor.
+	headers := make(map[string]string)
+	for _, k := range g.forwardHeaders {
+		if v := r.Header.Get(k); v != "" {
+			headers[http.CanonicalHeaderKey(k)] = v
+		}
+	}
+
+	var temp float32
+	if body.Temperature != nil {
+		temp = *body.Temperature
+	}
+
+	maxTokens := body.MaxCompletionTokens
+	if maxTokens == 0 {
+		maxTokens = body.MaxTokens
+	}
+
+	meta := map[string]any{}
+	if body.User != "" {
+		meta["user"] = body.User
+	}
+	if tenant, _ := r.Context().Value(tenantCtxKey{}).(string); tenant != "" {
+		meta[registry.MetaTenantID] = tenant
+	}
+
+	return kbxTypes.ChatRequest{
+		Headers:  headers,
+		Provider: provider,
+		Model:    model,
+		Messages: messages,
+		Temp:     temp,
+		Stream:   body.Stream,
+		Meta:     meta,
+
+		MaxTokens:        maxTokens,
+		TopP:             body.TopP,
+		Stop:             body.Stop,
+		Seed:             body.Seed,
+		FrequencyPenalty: body.FrequencyPenalty,
+		PresencePenalty:  body.PresencePenalty,
+		ReasoningEffort:  body.ReasoningEffort,
+		Tools:            tools,
+	}
+}
+
+func (g *Gateway) streamCompletion(ctx context.Context, w http.ResponseWriter, stream <-chan kbxTypes.ChatChunk, id string, created int64, model string) {
+	toolCalls := 0
+	err := sse.StreamChunks(ctx, w, stream, sse.StreamOptions{
+		Encode: func(chunk kbxTypes.ChatChunk) []sse.Event {
+			if chunk.IsError() {
+				return []sse.Event{jsonEvent(errorEnvelope{Error: apiError{Message: chunk.Error, Type: "api_error"}})}
+			}
+			events := []sse.Event{}
+			if chunk.HasContent() || chunk.HasReasoning() {
+				delta := streamDelta{Content: chunk.Content, ReasoningContent: chunk.Reasoning}
+				events = append(events, jsonEvent(newStreamChunk(id, created, model, delta, nil, nil)))
+			}
+			if chunk.HasToolCall() {
+				call := toToolCall(*chunk.ToolCall)
+				index := toolCalls
+				call.Index = &index
+				toolCalls++
+				events = append(events, jsonEvent(newStreamChunk(id, created, model, streamDelta{ToolCalls: []chatToolCall{call}}, nil, nil)))
+			}
+			if chunk.Done {
+				finish := finishReason(toolCalls)
+				events = append(events, jsonEvent(newStreamChunk(id, created, model, streamDelta{}, &finish, toUsage(chunk.Usage))))
+			}
+			return events
+		},
+	})
+	if errors.Is(err, sse.ErrFlushUnsupported) {
+		writeError(w, http.StatusInternalServerError, "api_error", "streaming not supported by response writer")
+		return
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		gl.Warnf("Gateway stream for %s ended with error: %v", id, err)
+	}
+}
+
+func (g *Gateway) writeCompletion(w http.ResponseWriter, stream <-chan kbxTypes.ChatChunk, id string, created int64, model string) {
+	var content, reasoning strings.Builder
+	var toolCalls []chatToolCall
+	var usage *kbxTypes.Usage
+	for chunk := range stream {
+		if chunk.IsTimeout() {
+			writeError(w, http.StatusGatewayTimeout, "timeout_error", chunk.Error)
+			for range stream {
+			}
+			return
+		}
+		if chunk.IsError() {
+			writeError(w, http.StatusBadGateway, "api_error", chunk.Error)
+			// Drain the stream so the provider goroutine can exit.
+			for range stream {
+			}
+			return
+		}
+		content.WriteString(chunk.Content)
+		reasoning.WriteString(chunk.Reasoning)
+		if chunk.HasToolCall() {
+			toolCalls = append(toolCalls, toToolCall(*chunk.ToolCall))
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+
+	writeJSON(w, http.StatusOK, chatCompletion{
+		ID:      id,
+		Object:  "chat.completion",
+		Created: created,
+		Model:   model,
+		Choices: []completionChoice{{
+			Index:        0,
+			Message:      chatMessage{Role: "assistant", Content: content.String(), ReasoningContent: reasoning.String(), ToolCalls: toolCalls},
+			FinishReason: finishReason(len(toolCalls)),
+		}},
+		Usage: toUsage(usage),
+	})
+}
+
+// finishReason is "tool_calls" when the answer requested tool calls, "stop" otherwise.
+func finishReason(toolCalls int) string {
+	if toolCalls > 0 {
+		return "tool_calls"
+	}
+	return "stop"
+}
+
+func splitModel(model string) (string, string) {
+	model = strings.TrimSpace(model)
+	if provider, name, ok := strings.Cut(model, "/"); ok {
+		return strings.ToLower(strings.TrimSpace(provider)), strings.TrimSpace(name)
+	}
+	return "", model
+}
+
+func exposedModel(provider, model string) string {
+	if model == "" {
+		return provider
+	}
+	return provider + "/" + model
+}
+
+func jsonEvent(v any) sse.Event {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return sse.Event{}
+	}
+	return sse.Event{Data: string(data)}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		gl.Warnf("Failed to encode gateway response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, typ, message string) {
+	writeJSON(w, status, errorEnvelope{Error: apiError{Message: message, Type: typ}})
+}
@@ -0,0 +1,217 @@
+package gateway
+
+import (
+	"encoding/json"
+
+	kbxTypes "github.com/kubex-ecosystem/kbx/types"
+)
+
+// chatCompletionRequest mirrors the subset of the OpenAI chat completions body we map to ChatRequest
+type chatCompletionRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	Temperature *float32      `json:"temperature,omitempty"`
+	Stream      bool          `json:"stream,omitempty"`
+	User        string        `json:"user,omitempty"`
+
+	MaxTokens           int           `json:"max_tokens,omitempty"`
+	MaxCompletionTokens int           `json:"max_completion_tokens,omitempty"`
+	TopP                float32       `json:"top_p,omitempty"`
+	Stop                stopSequences `json:"stop,omitempty"`
+	Seed                *int64        `json:"seed,omitempty"`
+	FrequencyPenalty    float32       `json:"frequency_penalty,omitempty"`
+	PresencePenalty     float32       `json:"presence_penalty,omitempty"`
+	ReasoningEffort     string        `json:"reasoning_effort,omitempty"`
+	Tools               []chatTool    `json:"tools,omitempty"`
+}
+
+// chatTool is an OpenAI tool declaration; only "function" tools are supported.
+type chatTool struct {
+	Type     string       `json:"type"`
+	Function toolFunction `json:"function"`
+}
+
+type toolFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+// stopSequences accepts OpenAI's "stop" as either a string or an array of strings.
+type stopSequences []string
+
+func (s *stopSequences) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		if one != "" {
+			*s = stopSequences{one}
+		}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*s = many
+	return nil
+}
+
+type chatMessage struct {
+	Role             string         `json:"role"`
+	Content          string         `json:"content"`
+	ReasoningContent string         `json:"reasoning_content,omitempty"`
+	ToolCalls        []chatToolCall `json:"tool_calls,omitempty"`
+	ToolCallID       string         `json:"tool_call_id,omitempty"`
+	Name             string         `json:"name,omitempty"`
+}
+
+// chatToolCall is a function call in OpenAI's format; Index is only set in stream deltas.
+type chatToolCall struct {
+	Index    *int             `json:"index,omitempty"`
+	ID       string           `json:"id,omitempty"`
+	Type     string           `json:"type"`
+	Function toolCallFunction `json:"function"`
+}
+
+type toolCallFunction struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
+}
+
+// chatCompletion is the non-streaming OpenAI response object
+type chatCompletion struct {
+	ID      string             `json:"id"`
+	Object  string             `json:"object"`
+	Created int64              `json:"created"`
+	Model   string             `json:"model"`
+	Choices []completionChoice `json:"choices"`
+	Usage   *completionUsage   `json:"usage,omitempty"`
+}
+
+type completionChoice struct {
+	Index        int         `json:"index"`
+	Message      chatMessage `json:"message"`
+	FinishReason string      `json:"finish_reason"`
+}
+
+// streamChunk is the OpenAI "chat.completion.chunk" object sent over SSE
+type streamChunk struct {
+	ID      string              `json:"id"`
+	Object  string              `json:"object"`
+	Created int64               `json:"created"`
+	Model   string              `json:"model"`
+	Choices []streamChunkChoice `json:"choices"`
+	Usage   *completionUsage    `json:"usage,omitempty"`
+}
+
+type streamChunkChoice struct {
+	Index        int         `json:"index"`
+	Delta        streamDelta `json:"delta"`
+	FinishReason *string     `json:"finish_reason"`
+}
+
+type streamDelta struct {
+	Role             string         `json:"role,omitempty"`
+	Content          string         `json:"content,omitempty"`
+	ReasoningContent string         `json:"reasoning_content,omitempty"`
+	ToolCalls        []chatToolCall `json:"tool_calls,omitempty"`
+}
+
+type completionUsage struct {
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	CostUSD          float64 `json:"cost_usd,omitempty"`
+	LatencyMs        int64   `json:"latency_ms,omitempty"`
+
+	CompletionTokensDetails *completionTokensDetails `json:"completion_tokens_details,omitempty"`
+}
+
+type completionTokensDetails struct {
+	ReasoningTokens int `json:"reasoning_tokens"`
+}
+
+type modelList struct {
+	Object string       `json:"object"`
+	Data   []modelEntry `json:"data"`
+}
+
+type modelEntry struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	OwnedBy string `json:"owned_by"`
+}
+
+type healthReport struct {
+	Status    string            `json:"status"`
+	Providers map[string]string `json:"providers"`
+}
+
+type errorEnvelope struct {
+	Error apiError `json:"error"`
+}
+
+type apiError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
+
+func newStreamChunk(id string, created int64, model string, delta streamDelta, finish *string, usage *completionUsage) streamChunk {
+	return streamChunk{
+		ID:      id,
+		Object:  "chat.completion.chunk",
+		Created: created,
+		Model:   model,
+		Choices: []streamChunkChoice{{
+			Index:        0,
+			Delta:        delta,
+			FinishReason: finish,
+		}},
+		Usage: usage,
+	}
+}
+
+// toToolCall converts a ChatChunk tool call to OpenAI's format, with the
+// arguments as a JSON string.
+func toToolCall(call kbxTypes.ToolCall) chatToolCall {
+	args, ok := call.Args.(string)
+	if !ok {
+		if call.Args == nil {
+			args = "{}"
+		} else if b, err := json.Marshal(call.Args); err == nil {
+			args = string(b)
+		}
+	}
+	return chatToolCall{ID: call.ID, Type: "function", Function: toolCallFunction{Name: call.Name, Arguments: args}}
+}
+
+// fromToolCall converts an OpenAI tool call back, decoding the arguments when they are JSON.
+func fromToolCall(call chatToolCall) kbxTypes.ToolCall {
+	var args any = call.Function.Arguments
+	var decoded map[string]any
+	if json.Unmarshal([]byte(call.Function.Arguments), &decoded) == nil {
+		args = decoded
+	}
+	return kbxTypes.ToolCall{ID: call.ID, Name: call.Function.Name, Args: args}
+}
+
+func toUsage(u *kbxTypes.Usage) *completionUsage {
+	if u == nil {
+		return nil
+	}
+	total := u.Tokens
+	if total == 0 {
+		total = u.Prompt + u.Completion
+	}
+	usage := &completionUsage{
+		PromptTokens:     u.Prompt,
+		CompletionTokens: u.Completion,
+		TotalTokens:      total,
+		CostUSD:          u.CostUSD,
+		LatencyMs:        u.Ms,
+	}
+	if u.Reasoning > 0 {
+		usage.CompletionTokensDetails = &completionTokensDetails{ReasoningTokens: u.Reasoning}
+	}
+	return usage
+}
@@ -5,10 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/kubex-ecosystem/kbx/tools/prompts"
 	providers "github.com/kubex-ecosystem/kbx/types"
 	gl "github.com/kubex-ecosystem/logz"
 	genai "google.golang.org/genai"
@@ -22,6 +24,7 @@ type geminiProvider struct {
 	defaultModel                string
 	baseURL                     string
 	client                      *genai.Client
+	vendorCaps                  map[string]*providers.ModelCapabilities
 	mu                          sync.Mutex
 }
 
@@ -70,6 +73,56 @@ func (g *geminiProvider) Available() error {
 	return nil
 }
 
+// ModelInfo describes the default model. Token limits and supported actions
+// reported by the Gemini models API refine the built-in descriptor once they
+// have been fetched; until then the capabilities catalog is used.
+func (g *geminiProvider) ModelInfo(ctx context.Context) (map[string]any, error) {
+	return modelInfo(g.name, "gemini", g.defaultModel, g.vendorCapabilities(g.defaultModel)), nil
+}
+
+// vendorCapabilities returns the Gemini model metadata fetched for model, or
+// nil while it is unknown. The first call starts the fetch in the background,
+// so callers (registry loading included) never wait on the Gemini API.
+func (g *geminiProvider) vendorCapabilities(model string) *providers.ModelCapabilities {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if caps, ok := g.vendorCaps[model]; ok {
+		return caps
+	}
+	if g.vendorCaps == nil {
+		g.vendorCaps = map[string]*providers.ModelCapabilities{}
+	}
+	// A nil entry marks the fetch as started; it stays nil if the fetch fails,
+	// so the API is not retried on every request.
+	g.vendorCaps[model] = nil
+	go g.fetchVendorCapabilities(model)
+	return nil
+}
+
+// fetchVendorCapabilities queries the Gemini models API for model, outside g.mu.
+func (g *geminiProvider) fetchVendorCapabilities(model string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	meta, err := g.client.Models.Get(ctx, model, nil)
+	if err != nil {
+		gl.Debugf("Gemini model metadata unavailable for '%s': %v", model, err)
+		return
+	}
+
+	caps, _ := lookupCapabilities("gemini", model, nil)
+	caps.Streaming = slices.Contains(meta.SupportedActions, "streamGenerateContent") || caps.Streaming
+	if meta.InputTokenLimit > 0 {
+		caps.ContextWindow = int(meta.InputTokenLimit)
+	}
+	if meta.OutputTokenLimit > 0 {
+		caps.MaxOutputTokens = int(meta.OutputTokenLimit)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.vendorCaps[model] = &caps
+}
+
 // Chat performs a chat completion request using Gemini's streaming API with the SDK
 func (g *geminiProvider) Chat(ctx context.Context, req providers.ChatRequest) (<-chan providers.ChatChunk, error) {
 	modelName := req.Model
@@ -82,15 +135,42 @@ func (g *geminiProvider) Chat(ctx context.Context, req providers.ChatRequest) (<
 
 	// Configuração base de geração
 	config := &genai.GenerateContentConfig{
-		Temperature:     &req.Temp,
-		MaxOutputTokens: int32(8192),
+		Temperature:      &req.Temp,
+		MaxOutputTokens:  int32(req.MaxTokens),
+		TopP:             optionalFloat(req.TopP),
+		TopK:             optionalFloat(float32(req.TopK)),
+		StopSequences:    req.Stop,
+		PresencePenalty:  optionalFloat(req.PresencePenalty),
+		FrequencyPenalty: optionalFloat(req.FrequencyPenalty),
+	}
+	if req.Seed != nil {
+		seed := int32(*req.Seed)
+		config.Seed = &seed
+	}
+	if budget := reasoningBudget(req); budget > 0 {
+		thinkingBudget := int32(budget)
+		config.ThinkingConfig = &genai.ThinkingConfig{IncludeThoughts: true, ThinkingBudget: &thinkingBudget}
+	}
+	if len(req.Tools) > 0 {
+		decls := make([]*genai.FunctionDeclaration, 0, len(req.Tools))
+		for _, spec := range req.Tools {
+			decl := &genai.FunctionDeclaration{Name: spec.Name, Description: spec.Description}
+			if spec.Parameters != nil {
+				decl.ParametersJsonSchema = spec.Parameters
+			}
+			decls = append(decls, decl)
+		}
+		config.Tools = []*genai.Tool{{FunctionDeclarations: decls}}
 	}
 
 	// 1. Handle special analysis requests
 	if analysisType, ok := req.Meta["analysisType"]; ok {
 		if projectContext, hasContext := req.Meta["projectContext"]; hasContext {
 			// Prepara o prompt de análise como SystemInstruction ou como Content
-			promptText := g.getAnalysisPrompt(projectContext.(string), analysisType.(string), req.Meta)
+			promptText, err := g.getAnalysisPrompt(fmt.Sprint(projectContext), fmt.Sprint(analysisType), req.Meta)
+			if err != nil {
+				return nil, gl.Errorf("failed to render analysis prompt: %v", err)
+			}
 			// Usamos o prompt de análise como o único Content da requisição.
 			// (A role é 'user' por ser o input do usuário/sistema)
 			contents = append(contents, genai.Text(promptText)...)
@@ -104,6 +184,38 @@ func (g *geminiProvider) Chat(ctx context.Context, req providers.ChatRequest) (<
 			if msg.Role == "assistant" || msg.Role == "model" {
 				role = "model" // O Gemini usa "model" para assistente
 			}
+			// Tool results go back as function responses; consecutive results share one Content.
+			if msg.Role == "tool" {
+				part := genai.NewPartFromFunctionResponse(msg.Name, map[string]any{"result": parseToolArgs(msg.Content)})
+				if msg.ToolCallID != msg.Name {
+					part.FunctionResponse.ID = msg.ToolCallID
+				}
+				if n := len(contents); n > 0 && contents[n-1].Role == "user" && contents[n-1].Parts[0].FunctionResponse != nil {
+					contents[n-1].Parts = append(contents[n-1].Parts, part)
+				} else {
+					contents = append(contents, &genai.Content{Role: "user", Parts: []*genai.Part{part}})
+				}
+				continue
+			}
+			if len(msg.ToolCalls) > 0 {
+				parts := []*genai.Part{}
+				if msg.Content != "" {
+					parts = append(parts, genai.NewPartFromText(msg.Content))
+				}
+				for _, call := range msg.ToolCalls {
+					args, _ := call.Args.(map[string]any)
+					if raw, ok := call.Args.(string); ok {
+						args, _ = parseToolArgs(raw).(map[string]any)
+					}
+					part := genai.NewPartFromFunctionCall(call.Name, args)
+					if call.ID != call.Name {
+						part.FunctionCall.ID = call.ID
+					}
+					parts = append(parts, part)
+				}
+				contents = append(contents, &genai.Content{Role: "model", Parts: parts})
+				continue
+			}
 			// Adiciona cada mensagem como um Content separado
 			// Ignora mensagens vazias
 			// Note: Cada msg vem com um Role e Content, então
@@ -141,6 +253,7 @@ func (g *geminiProvider) Chat(ctx context.Context, req providers.ChatRequest) (<
 
 		promptTokens := 0
 		completionTokens := 0
+		reasoningTokens := 0
 		totalTokens := 0
 		var fullContent strings.Builder
 
@@ -161,11 +274,26 @@ func (g *geminiProvider) Chat(ctx context.Context, req providers.ChatRequest) (<
 			// Extrair conteúdo (com tratamento de segurança para Text)
 			if len(resp.Candidates) > 0 && resp.Candidates[0].Content != nil {
 				for _, part := range resp.Candidates[0].Content.Parts {
-					if part != nil {
-						chunk := string(part.Text)
-						ch <- providers.ChatChunk{Content: chunk}
-						fullContent.WriteString(chunk)
+					if part == nil {
+						continue
+					}
+					if call := part.FunctionCall; call != nil {
+						// Gemini may omit call IDs; the name then identifies the call.
+						id := call.ID
+						if id == "" {
+							id = call.Name
+						}
+						ch <- providers.ChatChunk{ToolCall: &providers.ToolCall{ID: id, Name: call.Name, Args: call.Args}}
+						continue
 					}
+					// Thought summaries are reasoning, not part of the answer.
+					if part.Thought {
+						ch <- providers.ChatChunk{Reasoning: part.Text}
+						continue
+					}
+					chunk := string(part.Text)
+					ch <- providers.ChatChunk{Content: chunk}
+					fullContent.WriteString(chunk)
 				}
 			}
 
@@ -173,7 +301,8 @@ func (g *geminiProvider) Chat(ctx context.Context, req providers.ChatRequest) (<
 			if resp.UsageMetadata != nil {
 				promptTokens = int(resp.UsageMetadata.PromptTokenCount)
 				completionTokens = int(resp.UsageMetadata.CandidatesTokenCount)
-				totalTokens = promptTokens + completionTokens
+				reasoningTokens = int(resp.UsageMetadata.ThoughtsTokenCount)
+				totalTokens = promptTokens + completionTokens + reasoningTokens
 			}
 		}
 
@@ -194,6 +323,7 @@ func (g *geminiProvider) Chat(ctx context.Context, req providers.ChatRequest) (<
 				Completion: completionTokens,
 				Prompt:     promptTokens,
 				Tokens:     totalTokens,
+				Reasoning:  reasoningTokens,
 				Ms:         latencyMs,
 				CostUSD:    g.estimateCost(modelName, totalTokens),
 				Provider:   g.name,
@@ -225,8 +355,8 @@ func (g *geminiProvider) Notify(ctx context.Context, event providers.Notificatio
 	return nil
 }
 
-// getAnalysisPrompt generates analysis prompts (your original logic, cleaned up)
-func (g *geminiProvider) getAnalysisPrompt(projectContext, analysisType string, meta map[string]interface{}) string {
+// getAnalysisPrompt renders the built-in "project-analysis" prompt template
+func (g *geminiProvider) getAnalysisPrompt(projectContext, analysisType string, meta map[string]interface{}) (string, error) {
 	locale := "en-US"
 	if l, ok := meta["locale"]; ok {
 		if localeStr, ok := l.(string); ok {
@@ -237,22 +367,16 @@ func (g *geminiProvider) getAnalysisPrompt(projectContext, analysisType string,
 	if locale == "pt-BR" {
 		language = "Portuguese (Brazil)"
 	}
-	return fmt.Sprintf(`You are a world-class senior software architect and project management consultant with 20 years of experience.
-
-**Task:** Analyze the following software project based on the provided context.
-**Analysis Type:** %s
-**Response Language:** %s
-
-**Project Context:**
-%s
-
-**Instructions:**
-- Provide detailed, actionable insights
-- Focus on practical recommendations
-- Structure your response clearly
-- Be specific and concrete in your suggestions
-
-Analyze thoroughly and provide valuable insights.`, analysisType, language, projectContext)
+	version, _ := meta["promptVersion"].(string)
+	tpl, err := prompts.Default().Get("project-analysis", version)
+	if err != nil {
+		return "", err
+	}
+	return tpl.RenderText(map[string]any{
+		"analysis_type":   analysisType,
+		"project_context": projectContext,
+		"language":        language,
+	})
 }
 
 // estimateTokens provides a rough token estimation
@@ -1,7 +1,6 @@
 package registry
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -13,6 +12,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/kubex-ecosystem/kbx/tools/providers/sse"
 	providers "github.com/kubex-ecosystem/kbx/types"
 	gl "github.com/kubex-ecosystem/logz"
 )
@@ -46,9 +46,7 @@ func NewGroqProvider(name, baseURL, key, model string) (providers.ProviderExt, e
 		apiKey:            key,
 		defaultModel:      model,
 		baseURL:           baseURL,
-		client: &http.Client{
-			Timeout: time.Minute * 2, // Groq is so fast we can use shorter timeout
-		},
+		client:            newStreamingClient(),
 	}, nil
 }
 
@@ -56,6 +54,11 @@ func (p *groqProvider) Name() string {
 	return p.name
 }
 
+// ModelInfo describes the default model, including its capability descriptor.
+func (p *groqProvider) ModelInfo(ctx context.Context) (map[string]any, error) {
+	return modelInfo(p.name, "groq", p.defaultModel, nil), nil
+}
+
 func (p *groqProvider) Available() error {
 	if p.apiKey == "" {
 		return errors.New("groq API key not configured")
@@ -76,12 +79,21 @@ type groqRequest struct {
 	Temperature *float32      `json:"temperature,omitempty"`
 	MaxTokens   *int          `json:"max_tokens,omitempty"`
 	TopP        *float32      `json:"top_p,omitempty"`
+	Stop        []string      `json:"stop,omitempty"`
+	Seed        *int64        `json:"seed,omitempty"`
+	// Reasoning models only: effort level and "parsed" to split reasoning out of content.
+	ReasoningEffort string `json:"reasoning_effort,omitempty"`
+	ReasoningFormat string `json:"reasoning_format,omitempty"`
+	// Tools use the OpenAI function format.
+	Tools []map[string]any `json:"tools,omitempty"`
 }
 
 // groqMessage represents a message in Groq's format (OpenAI-compatible)
 type groqMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []map[string]any `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
 }
 
 // groqResponse represents the response from Groq API
@@ -114,8 +126,10 @@ type groqStreamChunk struct {
 	Choices []struct {
 		Index int `json:"index"`
 		Delta struct {
-			Role    string `json:"role,omitempty"`
-			Content string `json:"content,omitempty"`
+			Role      string                `json:"role,omitempty"`
+			Content   string                `json:"content,omitempty"`
+			Reasoning string                `json:"reasoning,omitempty"`
+			ToolCalls []openAIToolCallDelta `json:"tool_calls,omitempty"`
 		} `json:"delta"`
 		FinishReason *string `json:"finish_reason"`
 	} `json:"choices"`
@@ -137,11 +151,13 @@ func (p *groqProvider) Chat(ctx context.Context, req providers.ChatRequest) (<-c
 
 	// Convert messages to Groq format (same as OpenAI)
 	messages := make([]groqMessage, 0, len(req.Messages))
-	for _, msg := range req.Messages {
-		messages = append(messages, groqMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
-		})
+	for _, m := range toOpenAIMessages(req.Messages) {
+		msg := groqMessage{}
+		msg.Role, _ = m["role"].(string)
+		msg.Content, _ = m["content"].(string)
+		msg.ToolCalls, _ = m["tool_calls"].([]map[string]any)
+		msg.ToolCallID, _ = m["tool_call_id"].(string)
+		messages = append(messages, msg)
 	}
 
 	// Prepare request
@@ -161,9 +177,19 @@ func (p *groqProvider) Chat(ctx context.Context, req providers.ChatRequest) (<-c
 		groqReq.Temperature = &req.Temp
 	}
 
-	// Groq supports high token limits
-	maxTokens := 8192
-	groqReq.MaxTokens = &maxTokens
+	groqReq.MaxTokens = optionalInt(req.MaxTokens)
+	groqReq.TopP = optionalFloat(req.TopP)
+	groqReq.Stop = req.Stop
+	groqReq.Seed = req.Seed
+	if effort := reasoningEffort(req); effort != "" {
+		groqReq.ReasoningEffort = effort
+		groqReq.ReasoningFormat = "parsed"
+	}
+	if len(req.Tools) > 0 {
+		groqReq.Tools = toOpenAITools(req.Tools)
+	}
+	// Groq rejects penalties and has no top_k.
+	warnUnsupportedParams(p.name, req, ParamMaxTokens, ParamTopP, ParamStop, ParamSeed)
 
 	// Create request body
 	reqBody, err := json.Marshal(groqReq)
@@ -181,6 +207,7 @@ func (p *groqProvider) Chat(ctx context.Context, req providers.ChatRequest) (<-c
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
 	httpReq.Header.Set("Accept", "text/event-stream")
+	applyRequestHeaders(httpReq, req.Headers)
 
 	// Create response channel
 	responseChan := make(chan providers.ChatChunk, 100)
@@ -217,26 +244,30 @@ func (p *groqProvider) Chat(ctx context.Context, req providers.ChatRequest) (<-c
 		}
 
 		// Handle streaming response
-		scanner := bufio.NewScanner(resp.Body)
-		for scanner.Scan() {
-			line := scanner.Text()
-
-			// Skip empty lines and non-data lines
-			if !strings.HasPrefix(line, "data: ") {
-				continue
+		reader := sse.NewReader(resp.Body)
+		calls := &openAIToolCalls{}
+		for {
+			sseEvent, err := reader.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				responseChan <- providers.ChatChunk{
+					Content: "",
+					Done:    true,
+					Error:   fmt.Sprintf("Stream reading error: %v", err),
+				}
+				return
 			}
-
-			// Remove "data: " prefix
-			data := strings.TrimPrefix(line, "data: ")
 
 			// Check for end of stream
-			if data == "[DONE]" {
+			if sseEvent.IsDone() {
 				break
 			}
 
 			// Parse chunk
 			var chunk groqStreamChunk
-			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			if err := json.Unmarshal([]byte(sseEvent.Data), &chunk); err != nil {
 				continue // Skip invalid JSON
 			}
 
@@ -244,11 +275,12 @@ func (p *groqProvider) Chat(ctx context.Context, req providers.ChatRequest) (<-c
 			if len(chunk.Choices) > 0 {
 				choice := chunk.Choices[0]
 
-				// Send content chunk
-				if choice.Delta.Content != "" {
+				// Send content and reasoning chunk
+				if choice.Delta.Content != "" || choice.Delta.Reasoning != "" {
 					responseChunk := providers.ChatChunk{
-						Content: choice.Delta.Content,
-						Done:    false,
+						Content:   choice.Delta.Content,
+						Reasoning: choice.Delta.Reasoning,
+						Done:      false,
 					}
 
 					select {
@@ -258,6 +290,8 @@ func (p *groqProvider) Chat(ctx context.Context, req providers.ChatRequest) (<-c
 					}
 				}
 
+				calls.add(choice.Delta.ToolCalls)
+
 				// Handle completion
 				if choice.FinishReason != nil && *choice.FinishReason != "" {
 					// This is the final chunk, extract usage if available
@@ -270,13 +304,12 @@ func (p *groqProvider) Chat(ctx context.Context, req providers.ChatRequest) (<-c
 			}
 		}
 
-		if err := scanner.Err(); err != nil {
-			responseChan <- providers.ChatChunk{
-				Content: "",
-				Done:    true,
-				Error:   fmt.Sprintf("Stream reading error: %v", err),
+		for _, call := range calls.done() {
+			select {
+			case responseChan <- providers.ChatChunk{ToolCall: call}:
+			case <-ctx.Done():
+				return
 			}
-			return
 		}
 
 		// Calculate final metrics
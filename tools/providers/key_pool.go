@@ -0,0 +1,247 @@
+package registry
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	kbxTypes "github.com/kubex-ecosystem/kbx/types"
+
+	gl "github.com/kubex-ecosystem/logz"
+)
+
+// Key spreading strategies accepted in LLMProviderConfig.KeyStrategy.
+const (
+	KeyStrategyRoundRobin = "round_robin"
+	KeyStrategyLeastUsed  = "least_used"
+)
+
+var (
+	// RateLimitParkDuration is how long a key answering 429 stays out of rotation.
+	RateLimitParkDuration = time.Minute
+	// AuthParkDuration is how long a key answering 401/403 stays out of rotation.
+	AuthParkDuration = 10 * time.Minute
+
+	statusCodePattern = regexp.MustCompile(`(?i)error (\d{3})`)
+)
+
+// KeyUsage reports the traffic served by one pooled API key.
+type KeyUsage struct {
+	Provider    string    `json:"provider"`
+	Key         string    `json:"key"` // masked key or env reference
+	Requests    int64     `json:"requests"`
+	Failures    int64     `json:"failures"`
+	Tokens      int64     `json:"tokens"`
+	CostUSD     float64   `json:"cost_usd"`
+	InFlight    int       `json:"in_flight"`
+	ParkedUntil time.Time `json:"parked_until,omitempty"`
+	LastStatus  int       `json:"last_status,omitempty"`
+}
+
+type pooledKey struct {
+	label    string
+	provider kbxTypes.ProviderExt
+	usage    KeyUsage
+}
+
+// keyPoolProvider spreads Chat calls over one adapter instance per API key and
+// parks keys that hit rate limits or authentication errors.
+type keyPoolProvider struct {
+	kbxTypes.ProviderExt
+	name     string
+	strategy string
+	keys     []*pooledKey
+	next     int
+	mu       sync.Mutex
+}
+
+func newKeyPoolProvider(name, strategy string, members []*pooledKey) *keyPoolProvider {
+	strategy = strings.ToLower(strings.TrimSpace(strategy))
+	if strategy != KeyStrategyLeastUsed {
+		strategy = KeyStrategyRoundRobin
+	}
+	for _, k := range members {
+		k.usage.Provider = name
+		k.usage.Key = k.label
+	}
+	return &keyPoolProvider{
+		ProviderExt: members[0].provider,
+		name:        name,
+		strategy:    strategy,
+		keys:        members,
+	}
+}
+
+func (p *keyPoolProvider) Chat(ctx context.Context, req kbxTypes.ChatRequest) (<-chan kbxTypes.ChatChunk, error) {
+	key, err := p.acquire()
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := key.provider.Chat(ctx, req)
+	if err != nil {
+		p.release(key, kbxTypes.ChatChunk{Error: err.Error()}, true)
+		return nil, err
+	}
+
+	// The key is released once the upstream channel closes, whatever its last
+	// chunk was: streams may end without a Done chunk, and MapChunks stops
+	// calling back once ctx is cancelled.
+	var (
+		last     kbxTypes.ChatChunk
+		finished bool
+		once     sync.Once
+	)
+	return MapChunksThen(ctx, stream, func(c kbxTypes.ChatChunk) (kbxTypes.ChatChunk, bool) {
+		if c.Done || c.IsError() {
+			last, finished = c, true
+		}
+		return c, true
+	}, func() []kbxTypes.ChatChunk {
+		once.Do(func() { p.release(key, last, finished) })
+		return nil
+	}), nil
+}
+
+func (p *keyPoolProvider) SetModel(ctx context.Context, model string) error {
+	for _, k := range p.keys {
+		if err := k.provider.SetModel(ctx, model); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *keyPoolProvider) Available() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	for _, k := range p.keys {
+		if k.usage.ParkedUntil.Before(now) {
+			return k.provider.Available()
+		}
+	}
+	return gl.Errorf("all %d API keys for provider '%s' are parked", len(p.keys), p.name)
+}
+
+// Usage returns a snapshot of per-key usage.
+func (p *keyPoolProvider) Usage() []KeyUsage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]KeyUsage, 0, len(p.keys))
+	for _, k := range p.keys {
+		out = append(out, k.usage)
+	}
+	return out
+}
+
+func (p *keyPoolProvider) acquire() (*pooledKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var chosen *pooledKey
+	switch p.strategy {
+	case KeyStrategyLeastUsed:
+		for _, k := range p.keys {
+			if k.usage.ParkedUntil.After(now) {
+				continue
+			}
+			if chosen == nil ||
+				k.usage.InFlight < chosen.usage.InFlight ||
+				(k.usage.InFlight == chosen.usage.InFlight && k.usage.Requests < chosen.usage.Requests) {
+				chosen = k
+			}
+		}
+	default:
+		for i := 0; i < len(p.keys); i++ {
+			k := p.keys[(p.next+i)%len(p.keys)]
+			if k.usage.ParkedUntil.After(now) {
+				continue
+			}
+			chosen = k
+			p.next = (p.next + i + 1) % len(p.keys)
+			break
+		}
+	}
+
+	if chosen == nil {
+		return nil, gl.Errorf("all %d API keys for provider '%s' are parked", len(p.keys), p.name)
+	}
+	chosen.usage.InFlight++
+	chosen.usage.Requests++
+	return chosen, nil
+}
+
+// release frees k's in-flight slot. When finished, last is the Done or error
+// chunk of the call and updates the key's usage and status; otherwise the call
+// was abandoned and only the slot is freed.
+func (p *keyPoolProvider) release(k *pooledKey, last kbxTypes.ChatChunk, finished bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if k.usage.InFlight > 0 {
+		k.usage.InFlight--
+	}
+	if !finished {
+		return
+	}
+	if last.Usage != nil {
+		k.usage.Tokens += int64(last.Usage.Tokens)
+		k.usage.CostUSD += last.Usage.CostUSD
+	}
+	if !last.IsError() {
+		k.usage.LastStatus = 200
+		return
+	}
+
+	k.usage.Failures++
+	status := statusFromError(last.Error)
+	k.usage.LastStatus = status
+	switch status {
+	case 429:
+		k.usage.ParkedUntil = time.Now().Add(RateLimitParkDuration)
+		gl.Warnf("Provider '%s' key %s rate limited, parked for %v", p.name, k.label, RateLimitParkDuration)
+	case 401, 403:
+		k.usage.ParkedUntil = time.Now().Add(AuthParkDuration)
+		gl.Warnf("Provider '%s' key %s rejected (%d), parked for %v", p.name, k.label, status, AuthParkDuration)
+	}
+}
+
+// statusFromError extracts the HTTP status adapters embed in error chunks
+// ("API error 429: ...").
+func statusFromError(msg string) int {
+	m := statusCodePattern.FindStringSubmatch(msg)
+	if len(m) < 2 {
+		return 0
+	}
+	code, _ := strconv.Atoi(m[1])
+	return code
+}
+
+// maskKey renders a key reference safe for logs and usage reports.
+func maskKey(ref, value string) string {
+	if looksLikeEnvName(ref) {
+		return ref
+	}
+	if _, _, ok := splitSecretRef(ref); ok {
+		return ref
+	}
+	if len(value) <= 8 {
+		return "****"
+	}
+	return value[:4] + "…" + value[len(value)-4:]
+}
+
+// KeyUsage returns per-key usage for a provider configured with a key pool.
+// Providers with a single key report nil.
+func (r *Registry) KeyUsage(name string) []KeyUsage {
+	p, ok := r.ResolveProvider(name).(*keyPoolProvider)
+	if !ok {
+		return nil
+	}
+	return p.Usage()
+}
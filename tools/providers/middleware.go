@@ -0,0 +1,262 @@
+package registry
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/kubex-ecosystem/kbx/tools/security/bitflags"
+	kbxTypes "github.com/kubex-ecosystem/kbx/types"
+
+	gl "github.com/kubex-ecosystem/logz"
+)
+
+// ChatHandler is the signature shared by Registry.Chat and every middleware stage.
+type ChatHandler func(ctx context.Context, req kbxTypes.ChatRequest) (<-chan kbxTypes.ChatChunk, error)
+
+// Middleware wraps a ChatHandler. It may rewrite the request before calling
+// next and wrap the returned chunk stream (see MapChunks).
+type Middleware func(next ChatHandler) ChatHandler
+
+type middlewareEntry struct {
+	name  string
+	flags bitflags.SecFlag
+	mw    Middleware
+}
+
+type secFlagsCtxKey struct{}
+
+// WithSecFlags marks a context with the route's security flags. Middlewares
+// registered with UseFor run only when the route carries all of their bits.
+func WithSecFlags(ctx context.Context, flags bitflags.SecFlag) context.Context {
+	return context.WithValue(ctx, secFlagsCtxKey{}, flags)
+}
+
+// SecFlagsFrom returns the route flags stored by WithSecFlags.
+func SecFlagsFrom(ctx context.Context) bitflags.SecFlag {
+	flags, _ := ctx.Value(secFlagsCtxKey{}).(bitflags.SecFlag)
+	return flags
+}
+
+// Use appends middlewares that run on every Chat call, in registration order
+// (the first registered is the outermost).
+func (r *Registry) Use(mws ...Middleware) {
+	for _, mw := range mws {
+		r.UseFor(0, "", mw)
+	}
+}
+
+// UseFor appends a named middleware that runs only on routes whose flags
+// include every bit in flags. A zero flags value means "always".
+func (r *Registry) UseFor(flags bitflags.SecFlag, name string, mw Middleware) {
+	if r == nil || mw == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.middlewares = append(r.middlewares, middlewareEntry{name: name, flags: flags, mw: mw})
+}
+
+// Middlewares returns the names of the middlewares selected for the given flags, in order.
+func (r *Registry) Middlewares(flags bitflags.SecFlag) []string {
+	names := []string{}
+	for _, e := range r.selectMiddlewares(flags) {
+		name := e.name
+		if name == "" {
+			name = "<anonymous>"
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func (r *Registry) selectMiddlewares(flags bitflags.SecFlag) []middlewareEntry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	selected := make([]middlewareEntry, 0, len(r.middlewares))
+	for _, e := range r.middlewares {
+		if e.flags == 0 || flags&e.flags == e.flags {
+			selected = append(selected, e)
+		}
+	}
+	return selected
+}
+
+// chain composes the selected middlewares around the terminal handler.
+func (r *Registry) chain(ctx context.Context, terminal ChatHandler) ChatHandler {
+	entries := r.selectMiddlewares(SecFlagsFrom(ctx))
+	h := terminal
+	for i := len(entries) - 1; i >= 0; i-- {
+		h = entries[i].mw(h)
+	}
+	return h
+}
+
+// MapChunks returns a stream that applies fn to every chunk of in. Returning
+// false from fn drops the chunk. The returned channel closes after in closes.
+func MapChunks(ctx context.Context, in <-chan kbxTypes.ChatChunk, fn func(kbxTypes.ChatChunk) (kbxTypes.ChatChunk, bool)) <-chan kbxTypes.ChatChunk {
+	return MapChunksThen(ctx, in, fn, nil)
+}
+
+// MapChunksThen is MapChunks with a closing hook: then runs exactly once after
+// in has closed, whatever its last chunk was and also when ctx ended first.
+// The chunks it returns are sent before the returned channel closes, unless
+// ctx has ended.
+func MapChunksThen(ctx context.Context, in <-chan kbxTypes.ChatChunk, fn func(kbxTypes.ChatChunk) (kbxTypes.ChatChunk, bool), then func() []kbxTypes.ChatChunk) <-chan kbxTypes.ChatChunk {
+	out := make(chan kbxTypes.ChatChunk, cap(in))
+	go func() {
+		defer close(out)
+		send := func(c kbxTypes.ChatChunk) bool {
+			select {
+			case out <- c:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for chunk := range in {
+			mapped, keep := fn(chunk)
+			if !keep {
+				continue
+			}
+			if !send(mapped) {
+				// Keep draining so the provider goroutine can finish.
+				for range in {
+				}
+				break
+			}
+		}
+		if then == nil {
+			return
+		}
+		for _, c := range then() {
+			if ctx.Err() != nil || !send(c) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// -------------------------------- BUILT-IN MIDDLEWARES --------------------------------
+
+// LoggingMiddleware logs each request and the final usage or error of its stream.
+func LoggingMiddleware() Middleware {
+	return func(next ChatHandler) ChatHandler {
+		return func(ctx context.Context, req kbxTypes.ChatRequest) (<-chan kbxTypes.ChatChunk, error) {
+			start := time.Now()
+			gl.Debugf("chat request: provider=%s model=%s messages=%d", req.Provider, req.Model, len(req.Messages))
+			stream, err := next(ctx, req)
+			if err != nil {
+				gl.Warnf("chat request failed: provider=%s model=%s err=%v", req.Provider, req.Model, err)
+				return nil, err
+			}
+			return MapChunks(ctx, stream, func(c kbxTypes.ChatChunk) (kbxTypes.ChatChunk, bool) {
+				switch {
+				case c.IsError():
+					gl.Warnf("chat stream error: provider=%s model=%s after=%v err=%s", req.Provider, req.Model, time.Since(start), c.Error)
+				case c.Done && c.Usage != nil:
+					gl.Debugf("chat completed: provider=%s model=%s tokens=%d cost=%.6f after=%v", req.Provider, req.Model, c.Usage.Tokens, c.Usage.CostUSD, time.Since(start))
+				}
+				return c, true
+			}), nil
+		}
+	}
+}
+
+// HeaderMiddleware injects static headers into ChatRequest.Headers without
+// overriding values already set by the caller. Adapters forward these headers
+// to the vendor API.
+func HeaderMiddleware(headers map[string]string) Middleware {
+	return func(next ChatHandler) ChatHandler {
+		return func(ctx context.Context, req kbxTypes.ChatRequest) (<-chan kbxTypes.ChatChunk, error) {
+			merged := make(map[string]string, len(headers)+len(req.Headers))
+			for k, v := range headers {
+				merged[k] = v
+			}
+			for k, v := range req.Headers {
+				merged[k] = v
+			}
+			req.Headers = merged
+			return next(ctx, req)
+		}
+	}
+}
+
+// AuthMiddleware rejects the request when check returns an error. Register it
+// with UseFor(bitflags.SecAuth, ...) so it only guards authenticated routes.
+func AuthMiddleware(check func(ctx context.Context, req kbxTypes.ChatRequest) error) Middleware {
+	return func(next ChatHandler) ChatHandler {
+		return func(ctx context.Context, req kbxTypes.ChatRequest) (<-chan kbxTypes.ChatChunk, error) {
+			if check != nil {
+				if err := check(ctx, req); err != nil {
+					return nil, gl.Errorf("unauthorized chat request: %v", err)
+				}
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// SanitizeMiddleware strips control characters from message contents
+// (bitflags.SecSanitize).
+func SanitizeMiddleware() Middleware {
+	return func(next ChatHandler) ChatHandler {
+		return func(ctx context.Context, req kbxTypes.ChatRequest) (<-chan kbxTypes.ChatChunk, error) {
+			messages := make([]kbxTypes.Message, len(req.Messages))
+			for i, m := range req.Messages {
+				m.Content = stripControl(m.Content)
+				messages[i] = m
+			}
+			req.Messages = messages
+			return next(ctx, req)
+		}
+	}
+}
+
+// SanitizeBodyMiddleware validates the request body (bitflags.SecSanitizeBody):
+// roles must be known, empty messages are dropped and at least one must remain.
+// Messages carrying tool calls or a tool result are kept even without content.
+func SanitizeBodyMiddleware() Middleware {
+	return func(next ChatHandler) ChatHandler {
+		return func(ctx context.Context, req kbxTypes.ChatRequest) (<-chan kbxTypes.ChatChunk, error) {
+			messages := make([]kbxTypes.Message, 0, len(req.Messages))
+			for _, m := range req.Messages {
+				m.Role = strings.ToLower(strings.TrimSpace(m.Role))
+				switch m.Role {
+				case "system", "user", "assistant", "tool":
+				default:
+					return nil, gl.Errorf("%w: invalid message role '%s'", ErrInvalidRequest, m.Role)
+				}
+				if strings.TrimSpace(m.Content) == "" && len(m.ToolCalls) == 0 && m.ToolCallID == "" {
+					continue
+				}
+				messages = append(messages, m)
+			}
+			if len(messages) == 0 {
+				return nil, gl.Errorf("%w: no non-empty messages", ErrInvalidRequest)
+			}
+			req.Messages = messages
+			return next(ctx, req)
+		}
+	}
+}
+
+// UseDefaultSecurity registers the sanitize middlewares under their SecFlag bits.
+func (r *Registry) UseDefaultSecurity() {
+	r.UseFor(bitflags.SecSanitize, bitflags.SecSanitize.String(), SanitizeMiddleware())
+	r.UseFor(bitflags.SecSanitizeBody, bitflags.SecSanitizeBody.String(), SanitizeBodyMiddleware())
+}
+
+func stripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' || r == '\r' {
+			return r
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
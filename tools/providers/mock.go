@@ -0,0 +1,281 @@
+package registry
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	providers "github.com/kubex-ecosystem/kbx/types"
+	gl "github.com/kubex-ecosystem/logz"
+	"gopkg.in/yaml.v3"
+)
+
+// Mock script modes.
+const (
+	MockModeMatch    = "match"
+	MockModeSequence = "sequence"
+)
+
+// MockProvider replays scripted responses without calling any vendor. With no
+// script it echoes the last message back.
+type MockProvider struct {
+	providers.LLMProviderConfig `yaml:",inline" json:",inline" mapstructure:",squash"`
+	name                        string
+	defaultModel                string
+	script                      providers.LLMMockConfig
+	patterns                    []*regexp.Regexp
+	next                        int
+	calls                       []providers.ChatRequest
+	mu                          sync.Mutex
+}
+
+// NewMockProvider creates a mock provider. A non-HTTP baseURL is read as the
+// path of a YAML/JSON script (LLMMockConfig); the key is ignored.
+func NewMockProvider(name, baseURL, key, model string) (providers.ProviderExt, error) {
+	if model == "" {
+		model = "mock-1"
+	}
+	p := &MockProvider{
+		LLMProviderConfig: *providers.NewLLMProviderConfigType(name, baseURL, "", model),
+		name:              name,
+		defaultModel:      model,
+	}
+
+	if baseURL != "" && !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
+		data, err := os.ReadFile(os.ExpandEnv(baseURL))
+		if err != nil {
+			return nil, gl.Errorf("failed to read mock script: %v", err)
+		}
+		var script providers.LLMMockConfig
+		if err := yaml.Unmarshal(data, &script); err != nil {
+			return nil, gl.Errorf("failed to parse mock script '%s': %v", baseURL, err)
+		}
+		if err := p.SetScript(script); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// SetScript replaces the scripted responses and resets the sequence.
+func (p *MockProvider) SetScript(script providers.LLMMockConfig) error {
+	mode := strings.ToLower(strings.TrimSpace(script.Mode))
+	switch mode {
+	case "":
+		mode = MockModeMatch
+	case MockModeMatch, MockModeSequence:
+	default:
+		return gl.Errorf("unknown mock mode '%s'", script.Mode)
+	}
+	script.Mode = mode
+
+	patterns := make([]*regexp.Regexp, len(script.Responses))
+	for i, resp := range script.Responses {
+		if resp.Match == "" {
+			continue
+		}
+		re, err := regexp.Compile(resp.Match)
+		if err != nil {
+			return gl.Errorf("mock response %d has an invalid match pattern: %v", i, err)
+		}
+		patterns[i] = re
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.script = script
+	p.patterns = patterns
+	p.next = 0
+	return nil
+}
+
+// Calls returns the requests received so far.
+func (p *MockProvider) Calls() []providers.ChatRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]providers.ChatRequest(nil), p.calls...)
+}
+
+func (p *MockProvider) Name() string {
+	return p.name
+}
+
+// ModelInfo describes the default model, including its capability descriptor.
+func (p *MockProvider) ModelInfo(ctx context.Context) (map[string]any, error) {
+	return modelInfo(p.name, "mock", p.defaultModel, nil), nil
+}
+
+func (p *MockProvider) Available() error {
+	return nil
+}
+
+func (p *MockProvider) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+func (p *MockProvider) Notify(ctx context.Context, event providers.NotificationEvent) error {
+	return nil
+}
+
+func (p *MockProvider) Chat(ctx context.Context, req providers.ChatRequest) (<-chan providers.ChatChunk, error) {
+	model := req.Model
+	if model == "" {
+		model = p.defaultModel
+	}
+
+	resp, chunkSize, chunkDelay, err := p.respond(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.FailRequest {
+		return nil, gl.Errorf("mock provider '%s': %s", p.name, resp.Error)
+	}
+
+	chunks := resp.Chunks
+	if len(chunks) == 0 && resp.Content != "" {
+		chunks = splitRunes(resp.Content, chunkSize)
+	}
+
+	ch := make(chan providers.ChatChunk, 8)
+	go func() {
+		defer close(ch)
+		start := time.Now()
+
+		send := func(c providers.ChatChunk, delay time.Duration) bool {
+			if delay > 0 {
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+					select {
+					case ch <- providers.ChatChunk{Error: ctx.Err().Error(), Done: true}:
+					default:
+					}
+					return false
+				}
+			}
+			select {
+			case ch <- c:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		delay := time.Duration(resp.DelayMS) * time.Millisecond
+		// Reasoning is streamed ahead of the answer, as real thinking models do.
+		for _, r := range splitRunes(resp.Reasoning, chunkSize) {
+			if r == "" {
+				continue
+			}
+			if !send(providers.ChatChunk{Reasoning: r}, delay) {
+				return
+			}
+			delay = chunkDelay
+		}
+		completion := 0
+		for _, c := range chunks {
+			if !send(providers.ChatChunk{Content: c}, delay) {
+				return
+			}
+			completion += len(c)
+			delay = chunkDelay
+		}
+		for i, call := range resp.ToolCalls {
+			if call.ID == "" {
+				call.ID = "call_" + strconv.Itoa(i+1)
+			}
+			if !send(providers.ChatChunk{ToolCall: &call}, delay) {
+				return
+			}
+			delay = chunkDelay
+		}
+
+		usage := &providers.Usage{
+			Prompt:     resp.PromptTokens,
+			Completion: resp.CompletionTokens,
+			Reasoning:  len(resp.Reasoning) / 4,
+			CostUSD:    resp.CostUSD,
+			Provider:   p.name,
+			Model:      model,
+		}
+		// Roughly four characters per token when the script does not say.
+		if usage.Prompt == 0 {
+			for _, m := range req.Messages {
+				usage.Prompt += len(m.Content) / 4
+			}
+		}
+		if usage.Completion == 0 {
+			usage.Completion = completion / 4
+		}
+		usage.Tokens = usage.Prompt + usage.Completion + usage.Reasoning
+		usage.Ms = time.Since(start).Milliseconds()
+
+		send(providers.ChatChunk{Error: resp.Error, Done: true, Usage: usage}, delay)
+	}()
+	return ch, nil
+}
+
+// respond picks the scripted response for req and records the call.
+func (p *MockProvider) respond(req providers.ChatRequest) (providers.LLMMockResponse, int, time.Duration, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, req)
+
+	last := ""
+	if n := len(req.Messages); n > 0 {
+		last = req.Messages[n-1].Content
+	}
+	chunkSize := p.script.ChunkSize
+	chunkDelay := time.Duration(p.script.ChunkDelayMS) * time.Millisecond
+
+	responses := p.script.Responses
+	if len(responses) == 0 {
+		return providers.LLMMockResponse{Content: last}, chunkSize, chunkDelay, nil
+	}
+
+	if p.script.Mode == MockModeSequence {
+		i := p.next
+		if i >= len(responses) {
+			if p.script.Loop {
+				i = 0
+			} else {
+				i = len(responses) - 1
+			}
+		}
+		p.next = i + 1
+		return responses[i], chunkSize, chunkDelay, nil
+	}
+
+	for i, resp := range responses {
+		re := p.patterns[i]
+		if re == nil {
+			return resp, chunkSize, chunkDelay, nil
+		}
+		if m := re.FindStringSubmatchIndex(last); m != nil {
+			if resp.Expand {
+				resp.Content = string(re.ExpandString(nil, resp.Content, last, m))
+			}
+			return resp, chunkSize, chunkDelay, nil
+		}
+	}
+	return providers.LLMMockResponse{}, 0, 0, gl.Errorf("mock provider '%s': no scripted response matches %q", p.name, last)
+}
+
+// splitRunes cuts s into pieces of size runes; size <= 0 keeps s whole.
+func splitRunes(s string, size int) []string {
+	runes := []rune(s)
+	if size <= 0 || len(runes) <= size {
+		return []string{s}
+	}
+	out := make([]string, 0, len(runes)/size+1)
+	for len(runes) > 0 {
+		n := min(size, len(runes))
+		out = append(out, string(runes[:n]))
+		runes = runes[n:]
+	}
+	return out
+}
@@ -0,0 +1,362 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	kbxTypes "github.com/kubex-ecosystem/kbx/types"
+
+	gl "github.com/kubex-ecosystem/logz"
+)
+
+// Registry event kinds, carried in NotificationEvent.Metadata["event"].
+const (
+	EventProviderDown   = "provider_down"
+	EventBreakerOpened  = "breaker_opened"
+	EventBudgetExceeded = "budget_exceeded"
+)
+
+// notifyTimeout bounds the delivery of one registry event.
+const notifyTimeout = 30 * time.Second
+
+// AlertOptions selects where the registry's own events are sent.
+type AlertOptions struct {
+	// Channels are the notifier names events go to; every registered notifier when empty.
+	Channels []string
+	// Recipient is set on every event, e.g. an on-call address or channel.
+	Recipient string
+}
+
+// SetNotifier routes Notify and the registry's own events (provider down,
+// breaker opened, budget exceeded) through n. The first call also starts
+// WatchHealth in the background, so provider down events are raised; Close
+// stops it.
+func (r *Registry) SetNotifier(n *kbxTypes.NotifierRegistry, opts AlertOptions) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifier, r.alerts = n, opts
+	if r.stopHealth == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		r.stopHealth = cancel
+		go r.WatchHealth(ctx)
+	}
+}
+
+// Close stops the background health watch started by SetNotifier.
+func (r *Registry) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopHealth != nil {
+		r.stopHealth()
+		r.stopHealth = nil
+	}
+	return nil
+}
+
+// Notifier returns the notifier registry set with SetNotifier, if any.
+func (r *Registry) Notifier() *kbxTypes.NotifierRegistry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.notifier
+}
+
+// emit sends a registry event to every alert channel in the background. The
+// event is always logged, so it is not lost when no notifier is set.
+func (r *Registry) emit(kind, priority, subject, content string, meta map[string]any) {
+	gl.Warnf("%s: %s", subject, content)
+
+	r.mu.RLock()
+	notifier, opts := r.notifier, r.alerts
+	r.mu.RUnlock()
+	if notifier == nil {
+		return
+	}
+	channels := opts.Channels
+	if len(channels) == 0 {
+		channels = notifier.ListProviders()
+	}
+
+	metadata := maps.Clone(meta)
+	if metadata == nil {
+		metadata = make(map[string]any)
+	}
+	metadata["event"] = kind
+	now := time.Now()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+		for _, channel := range channels {
+			event := kbxTypes.NotificationEvent{
+				Type:      channel,
+				Recipient: opts.Recipient,
+				Subject:   subject,
+				Content:   content,
+				Priority:  priority,
+				Metadata:  metadata,
+				CreatedAt: now,
+			}
+			if err := notifier.Notify(ctx, event.AsNotifierEvent()); err != nil {
+				gl.Warnf("Failed to send '%s' event through '%s': %v", kind, channel, err)
+			}
+		}
+	}()
+}
+
+// -------------------------------- HEALTH --------------------------------
+
+// CheckHealth runs every provider's HealthCheck and returns the failures by
+// provider. A provider going from healthy to failing emits EventProviderDown.
+func (r *Registry) CheckHealth(ctx context.Context) map[string]error {
+	r.mu.RLock()
+	providers := maps.Clone(r.providers)
+	r.mu.RUnlock()
+
+	timeout := 10 * time.Second
+	if r.cfg != nil && r.cfg.Development.HealthCheck.TimeoutSec > 0 {
+		timeout = time.Duration(r.cfg.Development.HealthCheck.TimeoutSec) * time.Second
+	}
+
+	var (
+		mu       sync.Mutex
+		failures = make(map[string]error)
+		wg       sync.WaitGroup
+	)
+	for name, p := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			if err := p.HealthCheck(checkCtx); err != nil {
+				mu.Lock()
+				failures[name] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return failures
+	}
+
+	r.mu.Lock()
+	if r.down == nil {
+		r.down = make(map[string]bool)
+	}
+	var wentDown []string
+	for name := range providers {
+		_, failing := failures[name]
+		if failing && !r.down[name] {
+			wentDown = append(wentDown, name)
+		}
+		r.down[name] = failing
+	}
+	r.mu.Unlock()
+
+	for _, name := range wentDown {
+		r.emit(EventProviderDown, "high",
+			fmt.Sprintf("Provider '%s' is down", name),
+			fmt.Sprintf("Health check of provider '%s' failed: %v", name, failures[name]),
+			map[string]any{"provider": name})
+	}
+	return failures
+}
+
+// WatchHealth runs CheckHealth every LLMHealthCheckConfig.IntervalSec until
+// ctx ends. It returns at once when health checks are disabled.
+func (r *Registry) WatchHealth(ctx context.Context) {
+	if r == nil || r.cfg == nil || !r.cfg.Development.HealthCheck.Enabled {
+		return
+	}
+	interval := time.Duration(r.cfg.Development.HealthCheck.IntervalSec) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		r.CheckHealth(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// -------------------------------- CIRCUIT BREAKER --------------------------------
+
+// circuitBreaker follows LLMCircuitBreakerRule: MaxFailures consecutive
+// failures open it, it rejects requests for ResetTimeoutSec, then lets them
+// through half-open until SuccessThreshold successes close it again. Breakers
+// are kept per provider, and per tenant for a tenant's own provider instance.
+type circuitBreaker struct {
+	rule      kbxTypes.LLMCircuitBreakerRule
+	open      bool
+	openedAt  time.Time
+	failures  int
+	successes int
+}
+
+// breakerRule returns the provider's breaker rule, if breaking applies to it.
+func (r *Registry) breakerRule(name string) (kbxTypes.LLMCircuitBreakerRule, bool) {
+	if r == nil || r.cfg == nil || !r.cfg.Development.CircuitBreaker.Enabled {
+		return kbxTypes.LLMCircuitBreakerRule{}, false
+	}
+	cb := r.cfg.Development.CircuitBreaker
+	rule, ok := cb.PerProvider[name]
+	if !ok {
+		rule, ok = cb.PerProvider[normalizeProviderType(name, r.GetProviderConfig(name))]
+	}
+	if !ok {
+		rule = cb.Default
+	}
+	return rule, rule.MaxFailures > 0
+}
+
+// tenantBreakerKey is the breaker key of a tenant's own instance of provider name.
+func tenantBreakerKey(tenant, name string) string {
+	return "tenant:" + tenant + "/" + name
+}
+
+// breakerAllow returns an error while the breaker under key is open.
+func (r *Registry) breakerAllow(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.breakers[key]
+	if !ok || !b.open {
+		return nil
+	}
+	reset := time.Duration(b.rule.ResetTimeoutSec) * time.Second
+	if wait := reset - time.Since(b.openedAt); wait > 0 {
+		return gl.Errorf("circuit breaker for provider '%s' is open, retry in %s", key, wait.Round(time.Second))
+	}
+	return nil
+}
+
+// breakerRecord feeds the outcome of a request to the breaker under key,
+// which follows the rule of provider name.
+func (r *Registry) breakerRecord(key, name string, failed bool) {
+	rule, ok := r.breakerRule(name)
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	if r.breakers == nil {
+		r.breakers = make(map[string]*circuitBreaker)
+	}
+	b, ok := r.breakers[key]
+	if !ok {
+		b = &circuitBreaker{rule: rule}
+		r.breakers[key] = b
+	}
+	halfOpen := b.open && time.Since(b.openedAt) >= time.Duration(rule.ResetTimeoutSec)*time.Second
+	opened := false
+	switch {
+	case failed && (halfOpen || !b.open):
+		b.failures++
+		b.successes = 0
+		if halfOpen || b.failures >= rule.MaxFailures {
+			b.open, b.openedAt, opened = true, time.Now(), true
+		}
+	case !failed && halfOpen:
+		b.successes++
+		if b.successes >= max(rule.SuccessThreshold, 1) {
+			b.open, b.failures, b.successes = false, 0, 0
+		}
+	case !failed && !b.open:
+		b.failures = 0
+	}
+	failures := b.failures
+	r.mu.Unlock()
+
+	if opened {
+		r.emit(EventBreakerOpened, "high",
+			fmt.Sprintf("Circuit breaker opened for provider '%s'", key),
+			fmt.Sprintf("Provider '%s' failed %d consecutive requests; requests are refused for %ds.", key, failures, rule.ResetTimeoutSec),
+			map[string]any{"provider": name, "breaker": key, "failures": failures, "reset_timeout_sec": rule.ResetTimeoutSec})
+	}
+}
+
+// -------------------------------- BUDGET --------------------------------
+
+// checkBudget refuses requests once the tenant has spent its BudgetUSD. Spend
+// is only known once a request finishes, so requests admitted while the budget
+// was not yet reached may overshoot it by their own cost.
+func (r *Registry) checkBudget(tenant string) error {
+	tc := r.tenantConfig(tenant)
+	if tc == nil || tc.BudgetUSD <= 0 {
+		return nil
+	}
+	r.mu.RLock()
+	spent := r.spend[tenant]
+	r.mu.RUnlock()
+	if spent >= tc.BudgetUSD {
+		return gl.Errorf("tenant '%s' has exhausted its budget of $%.2f", tenant, tc.BudgetUSD)
+	}
+	return nil
+}
+
+// recordSpend adds the request's cost to the tenant's spend and emits
+// EventBudgetExceeded when it crosses the budget.
+func (r *Registry) recordSpend(tenant string, usage *kbxTypes.Usage) {
+	tc := r.tenantConfig(tenant)
+	if tc == nil || tc.BudgetUSD <= 0 || usage == nil || usage.CostUSD <= 0 {
+		return
+	}
+	r.mu.Lock()
+	if r.spend == nil {
+		r.spend = make(map[string]float64)
+	}
+	before := r.spend[tenant]
+	r.spend[tenant] = before + usage.CostUSD
+	after := r.spend[tenant]
+	r.mu.Unlock()
+
+	if before < tc.BudgetUSD && after >= tc.BudgetUSD {
+		r.emit(EventBudgetExceeded, "critical",
+			fmt.Sprintf("Tenant '%s' exceeded its budget", tenant),
+			fmt.Sprintf("Tenant '%s' has spent $%.4f of its $%.2f budget; further requests are refused.", tenant, after, tc.BudgetUSD),
+			map[string]any{"tenant": tenant, "spent_usd": after, "budget_usd": tc.BudgetUSD})
+	}
+}
+
+// TenantSpend returns what the tenant has spent so far, as counted for BudgetUSD.
+func (r *Registry) TenantSpend(tenant string) float64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.spend[tenant]
+}
+
+// recordOutcome feeds a finished request to the breaker under key and the
+// tenant budget. Requests ended by the caller do not count as provider failures.
+func (r *Registry) recordOutcome(ctx context.Context, tenant, key, name string, arm ArmResult) {
+	r.recordSpend(tenant, arm.Usage)
+	if ctx.Err() != nil {
+		return
+	}
+	r.breakerRecord(key, name, providerFailed(arm.Error))
+}
+
+// vendorStatus finds the HTTP status in adapter errors such as "API error 503: ...".
+var vendorStatus = regexp.MustCompile(`(?i)\berror (\d{3})\b`)
+
+// providerFailed reports whether a request error counts against the breaker:
+// 5xx responses, network errors and timeouts do; rejections with a 4xx status
+// are about the request and do not.
+func providerFailed(errMsg string) bool {
+	if errMsg == "" {
+		return false
+	}
+	if m := vendorStatus.FindStringSubmatch(errMsg); m != nil {
+		status, _ := strconv.Atoi(m[1])
+		return status >= 500
+	}
+	return true
+}
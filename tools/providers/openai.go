@@ -1,7 +1,6 @@
 package registry
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -12,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/kubex-ecosystem/kbx/tools/providers/sse"
 	providers "github.com/kubex-ecosystem/kbx/types"
 	gl "github.com/kubex-ecosystem/logz"
 )
@@ -41,9 +41,7 @@ func NewOpenAIProvider(name, baseURL, key, model string) (providers.ProviderExt,
 		baseURL:           baseURL,
 		apiKey:            key,
 		defaultModel:      model,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		client:            newStreamingClient(),
 	}, nil
 }
 
@@ -52,6 +50,11 @@ func (o *openaiProvider) Name() string {
 	return o.name
 }
 
+// ModelInfo describes the default model, including its capability descriptor.
+func (o *openaiProvider) ModelInfo(ctx context.Context) (map[string]any, error) {
+	return modelInfo(o.name, "openai", o.defaultModel, nil), nil
+}
+
 // Available checks if the provider is available
 func (o *openaiProvider) Available() error {
 	if o.apiKey == "" {
@@ -68,11 +71,51 @@ func (o *openaiProvider) Chat(ctx context.Context, req providers.ChatRequest) (<
 	}
 
 	body := map[string]any{
-		"model":       model,
-		"messages":    toOpenAIMessages(req.Messages),
-		"temperature": req.Temp,
-		"stream":      true,
+		"model":    model,
+		"messages": toOpenAIMessages(req.Messages),
+		"stream":   true,
+		// Ask for the final usage chunk so token counts (incl. reasoning) are reported.
+		"stream_options": map[string]any{"include_usage": true},
+	}
+	// Reasoning models (o-series, gpt-5) take max_completion_tokens and reject
+	// max_tokens, temperature, top_p and the penalties.
+	supported := []string{ParamMaxTokens, ParamStop, ParamSeed}
+	if caps, _ := lookupCapabilities("openai", model, nil); caps.Reasoning {
+		if req.MaxTokens > 0 {
+			body["max_completion_tokens"] = req.MaxTokens
+		}
+		if req.Temp != 0 {
+			gl.Debugf("Provider '%s' model '%s' is a reasoning model; ignoring temperature", o.name, model)
+		}
+	} else {
+		supported = append(supported, ParamTopP, ParamFrequencyPenalty, ParamPresencePenalty)
+		body["temperature"] = req.Temp
+		if req.MaxTokens > 0 {
+			body["max_tokens"] = req.MaxTokens
+		}
+		if req.TopP > 0 {
+			body["top_p"] = req.TopP
+		}
+		if req.FrequencyPenalty != 0 {
+			body["frequency_penalty"] = req.FrequencyPenalty
+		}
+		if req.PresencePenalty != 0 {
+			body["presence_penalty"] = req.PresencePenalty
+		}
+	}
+	if len(req.Stop) > 0 {
+		body["stop"] = req.Stop
+	}
+	if req.Seed != nil {
+		body["seed"] = *req.Seed
+	}
+	if effort := reasoningEffort(req); effort != "" {
+		body["reasoning_effort"] = effort
 	}
+	if len(req.Tools) > 0 {
+		body["tools"] = toOpenAITools(req.Tools)
+	}
+	warnUnsupportedParams(o.name, req, supported...)
 
 	bodyBytes, err := json.Marshal(body)
 	if err != nil {
@@ -87,6 +130,7 @@ func (o *openaiProvider) Chat(ctx context.Context, req providers.ChatRequest) (<
 
 	httpReq.Header.Set("Authorization", "Bearer "+o.apiKey)
 	httpReq.Header.Set("Content-Type", "application/json")
+	applyRequestHeaders(httpReq, req.Headers)
 
 	ch := make(chan providers.ChatChunk, 8)
 
@@ -107,45 +151,66 @@ func (o *openaiProvider) Chat(ctx context.Context, req providers.ChatRequest) (<
 			return
 		}
 
-		scanner := bufio.NewScanner(resp.Body)
-		totalTokens := 0
+		reader := sse.NewReader(resp.Body)
+		totalTokens, promptTokens, completionTokens, reasoningTokens := 0, 0, 0, 0
+		calls := &openAIToolCalls{}
 
-		for scanner.Scan() {
-			line := scanner.Text()
-			if !strings.HasPrefix(line, "data: ") {
-				continue
+		for {
+			event, err := reader.Next()
+			if errors.Is(err, io.EOF) {
+				break
 			}
-
-			data := strings.TrimPrefix(line, "data: ")
-			if data == "[DONE]" {
+			if err != nil {
+				ch <- providers.ChatChunk{Done: true, Error: fmt.Sprintf("stream reading error: %v", err)}
+				return
+			}
+			if event.IsDone() {
 				break
 			}
 
 			var chunk openaiStreamChunk
-			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			if err := json.Unmarshal([]byte(event.Data), &chunk); err != nil {
 				continue // Skip malformed chunks
 			}
 
-			if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
-				ch <- providers.ChatChunk{Content: chunk.Choices[0].Delta.Content}
+			if len(chunk.Choices) > 0 {
+				delta := chunk.Choices[0].Delta
+				// OpenAI-compatible servers stream reasoning as reasoning_content or reasoning.
+				reasoning := delta.ReasoningContent + delta.Reasoning
+				if delta.Content != "" || reasoning != "" {
+					ch <- providers.ChatChunk{Content: delta.Content, Reasoning: reasoning}
+				}
+				calls.add(delta.ToolCalls)
 			}
 
 			// Track token usage from usage field if present
 			if chunk.Usage != nil {
 				totalTokens = chunk.Usage.TotalTokens
+				promptTokens = chunk.Usage.PromptTokens
+				completionTokens = chunk.Usage.CompletionTokens
+				if chunk.Usage.CompletionTokensDetails != nil {
+					reasoningTokens = chunk.Usage.CompletionTokensDetails.ReasoningTokens
+				}
 			}
 		}
 
+		for _, call := range calls.done() {
+			ch <- providers.ChatChunk{ToolCall: call}
+		}
+
 		// Send final chunk with usage info
 		latencyMs := time.Since(startTime).Milliseconds()
 		ch <- providers.ChatChunk{
 			Done: true,
 			Usage: &providers.Usage{
-				Tokens:   totalTokens,
-				Ms:       latencyMs,
-				CostUSD:  estimateCost(model, totalTokens), // Simple cost estimation
-				Provider: o.name,
-				Model:    model,
+				Prompt:     promptTokens,
+				Completion: completionTokens,
+				Tokens:     totalTokens,
+				Reasoning:  reasoningTokens,
+				Ms:         latencyMs,
+				CostUSD:    estimateCost(model, totalTokens), // Simple cost estimation
+				Provider:   o.name,
+				Model:      model,
 			},
 		}
 	}()
@@ -159,26 +224,146 @@ func (o *openaiProvider) Notify(ctx context.Context, event providers.Notificatio
 }
 
 // toOpenAIMessages converts generic messages to OpenAI format
-func toOpenAIMessages(messages []providers.Message) []map[string]string {
-	result := make([]map[string]string, len(messages))
+func toOpenAIMessages(messages []providers.Message) []map[string]any {
+	result := make([]map[string]any, len(messages))
 	for i, msg := range messages {
-		result[i] = map[string]string{
+		m := map[string]any{
 			"role":    msg.Role,
 			"content": msg.Content,
 		}
+		if len(msg.ToolCalls) > 0 {
+			calls := make([]map[string]any, 0, len(msg.ToolCalls))
+			for _, call := range msg.ToolCalls {
+				calls = append(calls, map[string]any{
+					"id":   call.ID,
+					"type": "function",
+					"function": map[string]any{
+						"name":      call.Name,
+						"arguments": toolArgsString(call.Args),
+					},
+				})
+			}
+			m["tool_calls"] = calls
+		}
+		if msg.ToolCallID != "" {
+			m["tool_call_id"] = msg.ToolCallID
+		}
+		result[i] = m
 	}
 	return result
 }
 
+// toOpenAITools converts tool specs to OpenAI's function tool format
+func toOpenAITools(specs []providers.ToolSpec) []map[string]any {
+	tools := make([]map[string]any, 0, len(specs))
+	for _, spec := range specs {
+		params := spec.Parameters
+		if params == nil {
+			params = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		tools = append(tools, map[string]any{
+			"type": "function",
+			"function": map[string]any{
+				"name":        spec.Name,
+				"description": spec.Description,
+				"parameters":  params,
+			},
+		})
+	}
+	return tools
+}
+
+// openAIToolCallDelta is one streamed fragment of a tool call
+type openAIToolCallDelta struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments,omitempty"`
+	} `json:"function"`
+}
+
+// openAIToolCalls accumulates tool call fragments by index; arguments arrive in pieces.
+type openAIToolCalls struct {
+	order []int
+	calls map[int]*openAIToolCallDelta
+}
+
+func (a *openAIToolCalls) add(deltas []openAIToolCallDelta) {
+	for _, d := range deltas {
+		if a.calls == nil {
+			a.calls = map[int]*openAIToolCallDelta{}
+		}
+		call, ok := a.calls[d.Index]
+		if !ok {
+			call = &openAIToolCallDelta{Index: d.Index}
+			a.calls[d.Index] = call
+			a.order = append(a.order, d.Index)
+		}
+		if d.ID != "" {
+			call.ID = d.ID
+		}
+		call.Function.Name += d.Function.Name
+		call.Function.Arguments += d.Function.Arguments
+	}
+}
+
+// done returns the complete calls in the order they started.
+func (a *openAIToolCalls) done() []*providers.ToolCall {
+	out := make([]*providers.ToolCall, 0, len(a.order))
+	for _, idx := range a.order {
+		call := a.calls[idx]
+		out = append(out, &providers.ToolCall{ID: call.ID, Name: call.Function.Name, Args: parseToolArgs(call.Function.Arguments)})
+	}
+	return out
+}
+
+// parseToolArgs decodes a JSON arguments string, keeping it raw when it is not valid JSON.
+func parseToolArgs(raw string) any {
+	if strings.TrimSpace(raw) == "" {
+		return map[string]any{}
+	}
+	var args any
+	if err := json.Unmarshal([]byte(raw), &args); err != nil {
+		return raw
+	}
+	return args
+}
+
+// toolArgsString renders tool call arguments as the JSON string vendors expect.
+func toolArgsString(args any) string {
+	switch v := args.(type) {
+	case nil:
+		return "{}"
+	case string:
+		return v
+	case json.RawMessage:
+		return string(v)
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
 // openaiStreamChunk represents a streaming response chunk from OpenAI
 type openaiStreamChunk struct {
 	Choices []struct {
 		Delta struct {
-			Content string `json:"content"`
+			Content          string                `json:"content"`
+			ReasoningContent string                `json:"reasoning_content"`
+			Reasoning        string                `json:"reasoning"`
+			ToolCalls        []openAIToolCallDelta `json:"tool_calls"`
 		} `json:"delta"`
 	} `json:"choices"`
 	Usage *struct {
-		TotalTokens int `json:"total_tokens"`
+		PromptTokens            int `json:"prompt_tokens"`
+		CompletionTokens        int `json:"completion_tokens"`
+		TotalTokens             int `json:"total_tokens"`
+		CompletionTokensDetails *struct {
+			ReasoningTokens int `json:"reasoning_tokens"`
+		} `json:"completion_tokens_details,omitempty"`
 	} `json:"usage,omitempty"`
 }
 
@@ -0,0 +1,89 @@
+package registry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	kbxTypes "github.com/kubex-ecosystem/kbx/types"
+)
+
+// tokenBucket is a blocking token bucket: Capacity tokens, refilled at
+// RefillRate tokens per second.
+type tokenBucket struct {
+	mu       sync.Mutex
+	capacity float64
+	rate     float64
+	tokens   float64
+	last     time.Time
+}
+
+func newTokenBucket(cfg kbxTypes.LLMTokenBucket) *tokenBucket {
+	capacity := float64(cfg.Capacity)
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &tokenBucket{
+		capacity: capacity,
+		rate:     float64(cfg.RefillRate),
+		tokens:   capacity,
+		last:     time.Now(),
+	}
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+		b.last = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		wait := time.Second
+		if b.rate > 0 {
+			wait = time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		}
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// WaitRateLimit blocks until the provider's token bucket from
+// LLMDevelopmentConfig.RateLimit grants a request. It returns immediately when
+// rate limiting is disabled.
+func (r *Registry) WaitRateLimit(ctx context.Context, provider string) error {
+	if r == nil || r.cfg == nil || !r.cfg.Development.RateLimit.Enabled {
+		return nil
+	}
+	name := normalizeProviderName(provider)
+
+	r.mu.Lock()
+	if r.limiters == nil {
+		r.limiters = make(map[string]*tokenBucket)
+	}
+	bucket, ok := r.limiters[name]
+	if !ok {
+		rl := r.cfg.Development.RateLimit
+		cfg, has := rl.PerProvider[name]
+		if !has {
+			cfg = rl.Default
+		}
+		bucket = newTokenBucket(cfg)
+		r.limiters[name] = bucket
+	}
+	r.mu.Unlock()
+
+	return bucket.Wait(ctx)
+}
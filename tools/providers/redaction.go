@@ -0,0 +1,354 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	kbxTypes "github.com/kubex-ecosystem/kbx/types"
+
+	gl "github.com/kubex-ecosystem/logz"
+)
+
+// Built-in PII kinds detected by NewRedactor.
+const (
+	PIIEmail = "EMAIL"
+	PIICNPJ  = "CNPJ"
+	PIICPF   = "CPF"
+	PIICard  = "CARD"
+	PIIPhone = "PHONE"
+)
+
+const (
+	placeholderOpen  = "[["
+	placeholderClose = "]]"
+	// maxPlaceholderLen bounds how much streamed text is held back while
+	// waiting for a split placeholder to complete.
+	maxPlaceholderLen = 48
+)
+
+type piiPattern struct {
+	kind     string
+	re       *regexp.Regexp
+	validate func(match string) bool
+}
+
+// Redactor detects PII in message contents and replaces it with reversible
+// placeholders such as [[EMAIL_1]]. Patterns are applied in registration order.
+type Redactor struct {
+	mu       sync.RWMutex
+	patterns []piiPattern
+}
+
+// NewRedactor returns a Redactor with the built-in detectors for e-mails,
+// CNPJ, CPF, card numbers (Luhn-checked) and phone numbers.
+func NewRedactor() *Redactor {
+	r := &Redactor{}
+	r.patterns = []piiPattern{
+		{kind: PIIEmail, re: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)},
+		{kind: PIICNPJ, re: regexp.MustCompile(`\b\d{2}\.?\d{3}\.?\d{3}/?\d{4}-?\d{2}\b`), validate: validCNPJ},
+		{kind: PIICPF, re: regexp.MustCompile(`\b\d{3}\.?\d{3}\.?\d{3}-?\d{2}\b`), validate: validCPF},
+		{kind: PIICard, re: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), validate: validLuhn},
+		{kind: PIIPhone, re: regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?\(?\d{2,3}\)?[\s.-]?\d{4,5}[\s.-]?\d{4}\b`)},
+	}
+	return r
+}
+
+// validPIIKind matches the kinds accepted by AddPattern, e.g. CREDIT_CARD.
+var validPIIKind = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// AddPattern registers a custom detector. kind is used in the placeholder
+// and must be an upper-case identifier such as CREDIT_CARD.
+func (r *Redactor) AddPattern(kind, expr string) error {
+	kind = strings.ToUpper(strings.TrimSpace(kind))
+	if !validPIIKind.MatchString(kind) {
+		return gl.Errorf("invalid PII kind '%s'", kind)
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return gl.Errorf("invalid PII pattern for '%s': %v", kind, err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.patterns = append(r.patterns, piiPattern{kind: kind, re: re})
+	return nil
+}
+
+// Redact replaces every detected value in text with a placeholder recorded in vault.
+// The same value always maps to the same placeholder within a vault.
+func (r *Redactor) Redact(text string, vault *RedactionVault) string {
+	r.mu.RLock()
+	patterns := append([]piiPattern(nil), r.patterns...)
+	r.mu.RUnlock()
+
+	for _, p := range patterns {
+		text = p.re.ReplaceAllStringFunc(text, func(match string) string {
+			if p.validate != nil && !p.validate(match) {
+				return match
+			}
+			return vault.placeholder(p.kind, match)
+		})
+	}
+	return text
+}
+
+// redactArgs applies Redact to every string in tool call arguments.
+func (r *Redactor) redactArgs(args any, vault *RedactionVault) any {
+	return mapStrings(args, func(s string) string { return r.Redact(s, vault) })
+}
+
+// mapStrings returns a copy of v, a decoded JSON value, with fn applied to every string in it.
+func mapStrings(v any, fn func(string) string) any {
+	switch t := v.(type) {
+	case string:
+		return fn(t)
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, x := range t {
+			out[k] = mapStrings(x, fn)
+		}
+		return out
+	case map[string]string:
+		out := make(map[string]string, len(t))
+		for k, x := range t {
+			out[k] = fn(x)
+		}
+		return out
+	case []any:
+		out := make([]any, len(t))
+		for i, x := range t {
+			out[i] = mapStrings(x, fn)
+		}
+		return out
+	case []string:
+		out := make([]string, len(t))
+		for i, x := range t {
+			out[i] = fn(x)
+		}
+		return out
+	}
+	return v
+}
+
+// RedactionVault holds the placeholder ↔ original value mapping for one request.
+type RedactionVault struct {
+	mu       sync.Mutex
+	values   map[string]string
+	reverse  map[string]string
+	counters map[string]int
+}
+
+func NewRedactionVault() *RedactionVault {
+	return &RedactionVault{
+		values:   make(map[string]string),
+		reverse:  make(map[string]string),
+		counters: make(map[string]int),
+	}
+}
+
+// Len returns how many distinct values were redacted.
+func (v *RedactionVault) Len() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return len(v.values)
+}
+
+// Restore replaces every known placeholder in text with its original value.
+func (v *RedactionVault) Restore(text string) string {
+	if !strings.Contains(text, placeholderOpen) {
+		return text
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for placeholder, original := range v.values {
+		text = strings.ReplaceAll(text, placeholder, original)
+	}
+	return text
+}
+
+func (v *RedactionVault) placeholder(kind, value string) string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if p, ok := v.reverse[value]; ok {
+		return p
+	}
+	v.counters[kind]++
+	p := fmt.Sprintf("%s%s_%d%s", placeholderOpen, kind, v.counters[kind], placeholderClose)
+	v.values[p] = value
+	v.reverse[value] = p
+	return p
+}
+
+// streamRestorer restores placeholders in streamed text, holding back a
+// trailing fragment that may be the start of a placeholder split across chunks.
+type streamRestorer struct {
+	vault   *RedactionVault
+	pending string
+}
+
+func (s *streamRestorer) push(content string) string {
+	text := s.pending + content
+	s.pending = ""
+
+	cut := len(text)
+	if i := strings.LastIndex(text, placeholderOpen); i >= 0 && !strings.Contains(text[i:], placeholderClose) && len(text)-i < maxPlaceholderLen {
+		cut = i
+	} else if strings.HasSuffix(text, placeholderOpen[:1]) {
+		cut = len(text) - 1
+	}
+	s.pending = text[cut:]
+	return s.vault.Restore(text[:cut])
+}
+
+func (s *streamRestorer) flush() string {
+	text := s.vault.Restore(s.pending)
+	s.pending = ""
+	return text
+}
+
+// RedactionMiddleware redacts PII from every message before it reaches the
+// provider, tool call arguments included, and re-hydrates placeholders in the
+// streamed response and its tool calls.
+func RedactionMiddleware(redactor *Redactor) Middleware {
+	if redactor == nil {
+		redactor = NewRedactor()
+	}
+	return func(next ChatHandler) ChatHandler {
+		return func(ctx context.Context, req kbxTypes.ChatRequest) (<-chan kbxTypes.ChatChunk, error) {
+			vault := NewRedactionVault()
+			messages := make([]kbxTypes.Message, len(req.Messages))
+			for i, m := range req.Messages {
+				m.Content = redactor.Redact(m.Content, vault)
+				if len(m.ToolCalls) > 0 {
+					calls := make([]kbxTypes.ToolCall, len(m.ToolCalls))
+					for j, call := range m.ToolCalls {
+						call.Args = redactor.redactArgs(call.Args, vault)
+						calls[j] = call
+					}
+					m.ToolCalls = calls
+				}
+				messages[i] = m
+			}
+			req.Messages = messages
+
+			stream, err := next(ctx, req)
+			if err != nil || vault.Len() == 0 {
+				return stream, err
+			}
+
+			restorer := &streamRestorer{vault: vault}
+			reasoning := &streamRestorer{vault: vault}
+			return MapChunksThen(ctx, stream, func(c kbxTypes.ChatChunk) (kbxTypes.ChatChunk, bool) {
+				c.Content = restorer.push(c.Content)
+				c.Reasoning = reasoning.push(c.Reasoning)
+				if c.Done || c.IsError() {
+					c.Content += restorer.flush()
+					c.Reasoning += reasoning.flush()
+				}
+				if c.Error != "" {
+					c.Error = vault.Restore(c.Error)
+				}
+				if c.ToolCall != nil {
+					call := *c.ToolCall
+					call.Args = mapStrings(call.Args, vault.Restore)
+					c.ToolCall = &call
+				}
+				return c, c.Content != "" || c.Reasoning != "" || c.Done || c.IsError() || c.Usage != nil || c.ToolCall != nil
+			}, func() []kbxTypes.ChatChunk {
+				// The stream closed without a final chunk: release the held-back text.
+				tail := kbxTypes.ChatChunk{Content: restorer.flush(), Reasoning: reasoning.flush()}
+				if tail.Content == "" && tail.Reasoning == "" {
+					return nil
+				}
+				return []kbxTypes.ChatChunk{tail}
+			}), nil
+		}
+	}
+}
+
+// -------------------------------- VALIDATORS --------------------------------
+
+func digitsOf(s string) []int {
+	digits := make([]int, 0, len(s))
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, int(r-'0'))
+		}
+	}
+	return digits
+}
+
+func allSame(d []int) bool {
+	for _, x := range d[1:] {
+		if x != d[0] {
+			return false
+		}
+	}
+	return true
+}
+
+func validCPF(s string) bool {
+	d := digitsOf(s)
+	if len(d) != 11 || allSame(d) {
+		return false
+	}
+	for _, n := range []int{9, 10} {
+		sum := 0
+		for i := 0; i < n; i++ {
+			sum += d[i] * (n + 1 - i)
+		}
+		check := (sum * 10) % 11
+		if check == 10 {
+			check = 0
+		}
+		if check != d[n] {
+			return false
+		}
+	}
+	return true
+}
+
+func validCNPJ(s string) bool {
+	d := digitsOf(s)
+	if len(d) != 14 || allSame(d) {
+		return false
+	}
+	weights := []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}
+	for _, n := range []int{12, 13} {
+		sum := 0
+		for i := 0; i < n; i++ {
+			sum += d[i] * weights[len(weights)-n+i]
+		}
+		check := sum % 11
+		if check < 2 {
+			check = 0
+		} else {
+			check = 11 - check
+		}
+		if check != d[n] {
+			return false
+		}
+	}
+	return true
+}
+
+func validLuhn(s string) bool {
+	d := digitsOf(s)
+	if len(d) < 13 || len(d) > 19 {
+		return false
+	}
+	sum := 0
+	for i := len(d) - 1; i >= 0; i-- {
+		x := d[i]
+		if (len(d)-1-i)%2 == 1 {
+			x *= 2
+			if x > 9 {
+				x -= 9
+			}
+		}
+		sum += x
+	}
+	return sum%10 == 0
+}
@@ -3,22 +3,47 @@ package registry
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 
 	kbx "github.com/kubex-ecosystem/kbx"
+	kbxGet "github.com/kubex-ecosystem/kbx/get"
 	kbxMod "github.com/kubex-ecosystem/kbx/internal/module/kbx"
 	kbxTypes "github.com/kubex-ecosystem/kbx/types"
 
 	gl "github.com/kubex-ecosystem/logz"
 )
 
+var (
+	// ErrProviderNotFound is wrapped by Chat errors when no loaded provider can serve the request.
+	ErrProviderNotFound = errors.New("provider not found")
+	// ErrInvalidRequest is wrapped by Chat errors caused by the request itself
+	// (malformed messages, a provider or model the tenant may not use).
+	ErrInvalidRequest = errors.New("invalid chat request")
+)
+
 // Registry manages provider registration, configuration, and runtime resolution.
 type Registry struct {
-	cfg       *kbxTypes.LLMConfig
-	providers map[string]kbxTypes.ProviderExt
+	cfg         *kbxTypes.LLMConfig
+	providers   map[string]kbxTypes.ProviderExt
+	middlewares []middlewareEntry
+	limiters    map[string]*tokenBucket
+	// tenantProviders holds the bring-your-own-key instances, keyed by tenant then provider.
+	tenantProviders map[string]map[string]kbxTypes.ProviderExt
+	// notifier and alerts route Notify and the registry's own events (see notify.go).
+	notifier *kbxTypes.NotifierRegistry
+	alerts   AlertOptions
+	breakers map[string]*circuitBreaker
+	down     map[string]bool
+	spend    map[string]float64
+	// stopHealth ends the health watch started by SetNotifier.
+	stopHealth context.CancelFunc
+	mu         sync.RWMutex
 }
 
 // -------------------------------- REGISTRY CONSTRUCTORS --------------------------------
@@ -53,6 +78,11 @@ func Load(path string) (*Registry, error) {
 	}
 
 	cfg := buildRuntimeConfig(path, loadedCfg)
+	if capsFile := kbxGet.EnvOr(CapabilitiesPathEnv, cfg.CapabilitiesFile); capsFile != "" {
+		if err := LoadCapabilityOverrides(capsFile); err != nil {
+			gl.Warnf("Ignoring capability overrides: %v", err)
+		}
+	}
 	rg := NewRegistry(&cfg)
 	rg.instantiateProviders()
 
@@ -76,6 +106,17 @@ func (r *Registry) GetProviderConfig(name string) *kbxTypes.LLMProviderConfig {
 	return r.cfg.Providers[normalizeProviderName(name)]
 }
 
+// KeySources reports where a provider's API keys come from (env names, secret
+// references or masked literals) without exposing the keys themselves.
+func (r *Registry) KeySources(name string) []string {
+	name = normalizeProviderName(name)
+	sources := []string{}
+	for _, key := range resolveAPIKeys(name, r.GetProviderConfig(name)) {
+		sources = append(sources, maskKey(key.ref, key.value))
+	}
+	return sources
+}
+
 func (r *Registry) Providers() kbxTypes.LLMProvidersExtMap {
 	providers := make(map[string]kbxTypes.ProviderExt, len(r.providers))
 	for name, provider := range r.providers {
@@ -115,20 +156,60 @@ func (r *Registry) ResolveProvider(name string) kbxTypes.ProviderExt {
 	return provider
 }
 
+// Chat fills unset sampling parameters from the configured request defaults,
+// applies the caller's tenant overlay (see MetaTenantID), runs the request through the middlewares selected by the context's SecFlags
+// (see WithSecFlags) and then dispatches it to the resolved provider.
 func (r *Registry) Chat(ctx context.Context, req kbxTypes.ChatRequest) (<-chan kbxTypes.ChatChunk, error) {
-	p := r.ResolveProvider(req.Provider)
-	if p == nil {
-		return nil, gl.Errorf("provider '%s' not found", req.Provider)
+	return r.chain(ctx, r.dispatchChat)(ctx, r.applyTenantDefaults(r.applyRequestDefaults(req)))
+}
+
+func (r *Registry) dispatchChat(ctx context.Context, req kbxTypes.ChatRequest) (<-chan kbxTypes.ChatChunk, error) {
+	if req.Requires != nil {
+		provider, model, err := r.routeByCapabilities(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		req.Provider, req.Model = provider, model
+	}
+	tenant := r.tenantID(req)
+	if err := r.checkBudget(tenant); err != nil {
+		return nil, err
+	}
+	p, err := r.resolveForTenant(tenant, req)
+	if err != nil {
+		return nil, err
+	}
+	name := normalizeProviderName(req.Provider)
+	// A tenant's own instance has its own key, so it gets its own breaker.
+	key := name
+	r.mu.RLock()
+	shared := r.providers[name] == p
+	r.mu.RUnlock()
+	if !shared {
+		key = tenantBreakerKey(tenant, name)
+	}
+	if err := r.breakerAllow(key); err != nil {
+		return nil, err
 	}
-	return p.Chat(ctx, req)
+	if err := r.waitTenantRateLimit(ctx, tenant); err != nil {
+		return nil, err
+	}
+	stream, err := watchTimeouts(ctx, p, req, r.resolveTimeouts(req.Provider, req))
+	if err != nil {
+		r.recordOutcome(ctx, tenant, key, name, ArmResult{Error: err.Error()})
+		return nil, err
+	}
+	return observeArm(ctx, stream, req, func(arm ArmResult) { r.recordOutcome(ctx, tenant, key, name, arm) }), nil
 }
 
+// Notify sends event through the notifier registry set with SetNotifier; the
+// notifier is picked by event.Type ("discord", "email", ...).
 func (r *Registry) Notify(ctx context.Context, event kbxTypes.NotificationEvent) error {
-	p := r.ResolveProvider(event.Type)
-	if p == nil {
-		return gl.Errorf("provider '%s' not found", event.Type)
+	notifier := r.Notifier()
+	if notifier == nil {
+		return gl.Errorf("no notifier registry configured for '%s' notifications", event.Type)
 	}
-	return p.Notify(ctx, event)
+	return notifier.Notify(ctx, event.AsNotifierEvent())
 }
 
 // -------------------------------- PRIVATE INTERNAL METHODS --------------------------------
@@ -149,18 +230,41 @@ func (r *Registry) instantiateProviders() {
 			continue
 		}
 
-		key := resolveAPIKey(name, pc)
-		if key == "" {
+		keys := resolveAPIKeys(name, pc)
+		if len(keys) == 0 && keylessProviderTypes[providerType] {
+			keys = []resolvedKey{{ref: "none"}}
+		}
+		if len(keys) == 0 {
 			gl.Warnf("Skipping provider '%s' - no API key found in %s", name, pc.KeyEnv)
 			continue
 		}
 
-		provider, err := constructor(name, strings.TrimSpace(pc.BaseURL), key, strings.TrimSpace(pc.DefaultModel))
-		if err != nil {
-			gl.Warnf("Failed to initialize provider '%s': %v. This provider will be unavailable for use.", name, err)
+		members := make([]*pooledKey, 0, len(keys))
+		for _, key := range keys {
+			member, err := constructor(name, strings.TrimSpace(pc.BaseURL), key.value, strings.TrimSpace(pc.DefaultModel))
+			if err != nil {
+				gl.Warnf("Failed to initialize provider '%s' with key %s: %v", name, maskKey(key.ref, key.value), err)
+				continue
+			}
+			if mock, ok := member.(*MockProvider); ok && pc.Mock != nil {
+				if err := mock.SetScript(*pc.Mock); err != nil {
+					gl.Warnf("Invalid mock script for provider '%s': %v", name, err)
+					continue
+				}
+			}
+			members = append(members, &pooledKey{label: maskKey(key.ref, key.value), provider: member})
+		}
+		if len(members) == 0 {
+			gl.Warnf("Failed to initialize provider '%s'. This provider will be unavailable for use.", name)
 			continue
 		}
 
+		var provider kbxTypes.ProviderExt = members[0].provider
+		if len(members) > 1 {
+			provider = newKeyPoolProvider(name, pc.KeyStrategy, members)
+			gl.Debugf("Provider '%s' pooling %d API keys (%s)", name, len(members), provider.(*keyPoolProvider).strategy)
+		}
+
 		r.providers[name] = provider
 
 		if info, err := provider.ModelInfo(context.Background()); err == nil {
@@ -176,6 +280,12 @@ var providerConstructors = map[string]func(name, baseURL, key, model string) (kb
 	"gemini":    NewGeminiProvider,
 	"anthropic": NewAnthropicProvider,
 	"groq":      NewGroqProvider,
+	"mock":      NewMockProvider,
+}
+
+// keylessProviderTypes are instantiated even when no API key resolves.
+var keylessProviderTypes = map[string]bool{
+	"mock": true,
 }
 
 func buildRuntimeConfig(path string, loaded *kbxTypes.LLMConfig) kbxTypes.LLMConfig {
@@ -207,6 +317,15 @@ func buildRuntimeConfig(path string, loaded *kbxTypes.LLMConfig) kbxTypes.LLMCon
 	if loaded.License != "" {
 		cfg.License = loaded.License
 	}
+	if loaded.CapabilitiesFile != "" {
+		cfg.CapabilitiesFile = loaded.CapabilitiesFile
+	}
+	if len(loaded.Tenants) > 0 {
+		cfg.Tenants = make(map[string]*kbxTypes.LLMTenantConfig, len(loaded.Tenants))
+		for tenant, tc := range loaded.Tenants {
+			cfg.Tenants[strings.TrimSpace(tenant)] = normalizeTenantConfig(tc)
+		}
+	}
 
 	if len(loaded.Providers) > 0 {
 		cfg.Providers = make(kbxTypes.LLMProvidersMap, len(loaded.Providers))
@@ -241,7 +360,26 @@ func normalizeProviderConfig(name string, providerCfg *kbxTypes.LLMProviderConfi
 		}
 	}
 
-	return kbxTypes.NewLLMProviderConfigType(name, baseURL, keyEnv, defaultModel)
+	normalized := kbxTypes.NewLLMProviderConfigType(name, baseURL, keyEnv, defaultModel)
+	if fallback != nil {
+		normalized.KeyEnvs = fallback.KeyEnvs
+		normalized.KeyStrategy = fallback.KeyStrategy
+	}
+	if providerCfg != nil {
+		if len(providerCfg.KeyEnvs) > 0 {
+			normalized.KeyEnvs = providerCfg.KeyEnvs
+		}
+		if strings.TrimSpace(providerCfg.KeyStrategy) != "" {
+			normalized.KeyStrategy = strings.TrimSpace(providerCfg.KeyStrategy)
+		}
+		if strings.TrimSpace(providerCfg.ProviderType) != "" {
+			normalized.ProviderType = strings.ToLower(strings.TrimSpace(providerCfg.ProviderType))
+		}
+		if providerCfg.Mock != nil {
+			normalized.Mock = providerCfg.Mock
+		}
+	}
+	return normalized
 }
 
 func normalizeProviderName(name string) string {
@@ -258,13 +396,43 @@ func normalizeProviderType(name string, providerCfg *kbxTypes.LLMProviderConfig)
 	return normalizeProviderName(name)
 }
 
-func resolveAPIKey(name string, providerCfg *kbxTypes.LLMProviderConfig) string {
+func resolveAPIKey(name string, providerCfg *kbxTypes.LLMProviderConfig) (string, string) {
 	for _, candidate := range apiKeyCandidates(name, providerCfg) {
 		if value := resolveCandidateValue(candidate); value != "" {
-			return value
+			return candidate, value
 		}
 	}
-	return ""
+	return "", ""
+}
+
+type resolvedKey struct {
+	ref   string
+	value string
+}
+
+// resolveAPIKeys returns the primary key followed by every resolvable entry of
+// KeyEnvs, without duplicates.
+func resolveAPIKeys(name string, providerCfg *kbxTypes.LLMProviderConfig) []resolvedKey {
+	keys := []resolvedKey{}
+	appendKey := func(ref, value string) {
+		if value == "" {
+			return
+		}
+		for _, existing := range keys {
+			if existing.value == value {
+				return
+			}
+		}
+		keys = append(keys, resolvedKey{ref: ref, value: value})
+	}
+
+	appendKey(resolveAPIKey(name, providerCfg))
+	if providerCfg != nil {
+		for _, ref := range providerCfg.KeyEnvs {
+			appendKey(strings.TrimSpace(ref), resolveCandidateValue(ref))
+		}
+	}
+	return keys
 }
 
 func apiKeyCandidates(name string, providerCfg *kbxTypes.LLMProviderConfig) []string {
@@ -297,6 +465,10 @@ func resolveCandidateValue(candidate string) string {
 		return ""
 	}
 
+	if resolver, ref, ok := splitSecretRef(candidate); ok {
+		return resolveSecretRef(resolver, candidate, ref)
+	}
+
 	if expanded := strings.TrimSpace(os.ExpandEnv(candidate)); expanded != "" && expanded != candidate {
 		return expanded
 	}
@@ -340,3 +512,15 @@ func defaultKeyEnv(providerType string) string {
 		return ""
 	}
 }
+
+// applyRequestHeaders forwards ChatRequest.Headers to the vendor request without
+// letting callers override credentials set by the adapter.
+func applyRequestHeaders(httpReq *http.Request, headers map[string]string) {
+	for k, v := range headers {
+		switch strings.ToLower(k) {
+		case "authorization", "x-api-key", "x-goog-api-key", "content-length", "host":
+			continue
+		}
+		httpReq.Header.Set(k, v)
+	}
+}
@@ -0,0 +1,145 @@
+package registry
+
+import (
+	"slices"
+	"strings"
+
+	kbxTypes "github.com/kubex-ecosystem/kbx/types"
+
+	gl "github.com/kubex-ecosystem/logz"
+)
+
+// Sampling parameter names, as used by adapters when declaring support.
+const (
+	ParamMaxTokens        = "max_tokens"
+	ParamTopP             = "top_p"
+	ParamTopK             = "top_k"
+	ParamStop             = "stop"
+	ParamSeed             = "seed"
+	ParamFrequencyPenalty = "frequency_penalty"
+	ParamPresencePenalty  = "presence_penalty"
+)
+
+// Reasoning effort levels accepted in ChatRequest.ReasoningEffort.
+const (
+	ReasoningLow    = "low"
+	ReasoningMedium = "medium"
+	ReasoningHigh   = "high"
+)
+
+// Token budgets used when only an effort level is given.
+var reasoningBudgets = map[string]int{
+	ReasoningLow:    1024,
+	ReasoningMedium: 4096,
+	ReasoningHigh:   16384,
+}
+
+// reasoningBudget returns the token budget requested for reasoning, or 0 when
+// reasoning was not requested.
+func reasoningBudget(req kbxTypes.ChatRequest) int {
+	if req.ReasoningBudget > 0 {
+		return req.ReasoningBudget
+	}
+	return reasoningBudgets[strings.ToLower(strings.TrimSpace(req.ReasoningEffort))]
+}
+
+// reasoningEffort returns the effort level requested, deriving it from the
+// budget when only a budget is given.
+func reasoningEffort(req kbxTypes.ChatRequest) string {
+	if effort := strings.ToLower(strings.TrimSpace(req.ReasoningEffort)); effort != "" {
+		return effort
+	}
+	switch budget := req.ReasoningBudget; {
+	case budget <= 0:
+		return ""
+	case budget <= reasoningBudgets[ReasoningLow]:
+		return ReasoningLow
+	case budget <= reasoningBudgets[ReasoningMedium]:
+		return ReasoningMedium
+	default:
+		return ReasoningHigh
+	}
+}
+
+// applyRequestDefaults fills the request's unset (zero) sampling fields from
+// LLMDevelopmentConfig.Defaults. Temperature and top_p are filled only when the
+// caller set neither, so a caller choosing one never gets the other injected.
+func (r *Registry) applyRequestDefaults(req kbxTypes.ChatRequest) kbxTypes.ChatRequest {
+	if r == nil || r.cfg == nil {
+		return req
+	}
+	d := r.cfg.Development.Defaults
+
+	if req.Temp == 0 && req.TopP == 0 {
+		req.Temp = float32(d.Temperature)
+		req.TopP = float32(d.TopP)
+	}
+	if req.MaxTokens == 0 {
+		req.MaxTokens = d.MaxTokens
+	}
+	if req.FrequencyPenalty == 0 {
+		req.FrequencyPenalty = float32(d.FrequencyPenalty)
+	}
+	if req.PresencePenalty == 0 {
+		req.PresencePenalty = float32(d.PresencePenalty)
+	}
+	if d.Stream {
+		req.Stream = true
+	}
+	return req
+}
+
+// setParams lists the sampling parameters set on req.
+func setParams(req kbxTypes.ChatRequest) []string {
+	set := []string{}
+	if req.MaxTokens > 0 {
+		set = append(set, ParamMaxTokens)
+	}
+	if req.TopP > 0 {
+		set = append(set, ParamTopP)
+	}
+	if req.TopK > 0 {
+		set = append(set, ParamTopK)
+	}
+	if len(req.Stop) > 0 {
+		set = append(set, ParamStop)
+	}
+	if req.Seed != nil {
+		set = append(set, ParamSeed)
+	}
+	if req.FrequencyPenalty != 0 {
+		set = append(set, ParamFrequencyPenalty)
+	}
+	if req.PresencePenalty != 0 {
+		set = append(set, ParamPresencePenalty)
+	}
+	return set
+}
+
+// warnUnsupportedParams logs the parameters set on req that the provider ignores.
+func warnUnsupportedParams(provider string, req kbxTypes.ChatRequest, supported ...string) {
+	ignored := []string{}
+	for _, param := range setParams(req) {
+		if !slices.Contains(supported, param) {
+			ignored = append(ignored, param)
+		}
+	}
+	if len(ignored) > 0 {
+		gl.Warnf("Provider '%s' does not support %s; ignoring", provider, strings.Join(ignored, ", "))
+	}
+}
+
+// optionalFloat returns nil for unset (zero) values so they are omitted from vendor bodies.
+func optionalFloat(v float32) *float32 {
+	if v == 0 {
+		return nil
+	}
+	return &v
+}
+
+func optionalInt(v int) *int {
+	if v == 0 {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,110 @@
+package registry
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	kbxGet "github.com/kubex-ecosystem/kbx/get"
+	"github.com/kubex-ecosystem/kbx/tools/security/external"
+	sci "github.com/kubex-ecosystem/kbx/tools/security/interfaces"
+	"github.com/kubex-ecosystem/kbx/tools/security/storage"
+
+	gl "github.com/kubex-ecosystem/logz"
+)
+
+// SecretResolver resolves the part of a key reference after "<scheme>:".
+type SecretResolver func(ref string) (string, error)
+
+var (
+	secretResolversMu sync.RWMutex
+	secretResolvers   = map[string]SecretResolver{
+		"keyring": resolveKeyringSecret,
+		"vault":   resolveVaultSecret,
+		"file":    resolveFileSecret,
+	}
+
+	// NewKeyringService builds the keyring backend used by "keyring:" references.
+	NewKeyringService = func(service, name string) sci.IKeyringService {
+		return external.NewFileKeyringService(service, name)
+	}
+	// NewVaultStorage builds the Vault backend used by "vault:" references.
+	// Address and token come from VAULT_ADDR and VAULT_TOKEN.
+	NewVaultStorage = func(mount, path, field string) (storage.ISecretStorage, error) {
+		return storage.NewVaultSecretStorage(
+			kbxGet.EnvOr("VAULT_ADDR", "http://127.0.0.1:8200"),
+			os.Getenv("VAULT_TOKEN"),
+			mount, path, field,
+		)
+	}
+)
+
+// RegisterSecretScheme adds or replaces the resolver for "<scheme>:<ref>" key references.
+func RegisterSecretScheme(scheme string, resolver SecretResolver) {
+	scheme = strings.ToLower(strings.TrimSpace(scheme))
+	if scheme == "" || resolver == nil {
+		return
+	}
+	secretResolversMu.Lock()
+	defer secretResolversMu.Unlock()
+	secretResolvers[scheme] = resolver
+}
+
+// splitSecretRef reports whether candidate uses a registered secret scheme.
+func splitSecretRef(candidate string) (SecretResolver, string, bool) {
+	scheme, ref, ok := strings.Cut(candidate, ":")
+	if !ok {
+		return nil, "", false
+	}
+	secretResolversMu.RLock()
+	resolver, known := secretResolvers[strings.ToLower(scheme)]
+	secretResolversMu.RUnlock()
+	return resolver, ref, known
+}
+
+// resolveSecretRef resolves a scheme-prefixed key reference. Failures are
+// logged without the secret and yield an empty value.
+func resolveSecretRef(resolver SecretResolver, candidate, ref string) string {
+	value, err := resolver(ref)
+	if err != nil {
+		gl.Warnf("Failed to resolve API key reference '%s': %v", candidate, err)
+		return ""
+	}
+	return strings.TrimSpace(value)
+}
+
+// keyring:<service>/<name>
+func resolveKeyringSecret(ref string) (string, error) {
+	service, name, ok := strings.Cut(strings.TrimSpace(ref), "/")
+	if !ok || service == "" || name == "" {
+		return "", gl.Errorf("keyring reference must be keyring:<service>/<name>")
+	}
+	return NewKeyringService(service, name).RetrievePassword()
+}
+
+// vault:<mount>/<path>#<field>
+func resolveVaultSecret(ref string) (string, error) {
+	location, field, ok := strings.Cut(strings.TrimSpace(ref), "#")
+	mount, path, hasPath := strings.Cut(location, "/")
+	if !ok || !hasPath || mount == "" || path == "" || field == "" {
+		return "", gl.Errorf("vault reference must be vault:<mount>/<path>#<field>")
+	}
+	backend, err := NewVaultStorage(mount, path, field)
+	if err != nil {
+		return "", err
+	}
+	return backend.RetrievePassword()
+}
+
+// file:<path>
+func resolveFileSecret(ref string) (string, error) {
+	path := os.ExpandEnv(strings.TrimSpace(ref))
+	if path == "" {
+		return "", gl.Errorf("file reference must be file:<path>")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,137 @@
+// Package sse implements Server-Sent Events decoding for vendor streams and
+// encoding of ChatChunk streams for HTTP clients.
+package sse
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DoneSentinel is the data payload OpenAI-compatible APIs send to close a stream.
+const DoneSentinel = "[DONE]"
+
+// Event is a single dispatched Server-Sent Event.
+type Event struct {
+	ID    string
+	Event string
+	Data  string
+	Retry time.Duration
+}
+
+// IsDone reports whether the event carries the DoneSentinel payload.
+func (e Event) IsDone() bool { return strings.TrimSpace(e.Data) == DoneSentinel }
+
+// Reader decodes an SSE stream following the WHATWG event-stream rules:
+// multi-line data fields are joined with "\n", comment lines are ignored,
+// "retry" updates the reconnection delay and lines have no length limit.
+type Reader struct {
+	r      *bufio.Reader
+	lastID string
+	retry  time.Duration
+}
+
+// NewReader wraps r in an SSE decoder.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReaderSize(r, 64*1024)}
+}
+
+// LastEventID returns the last id field seen on the stream.
+func (r *Reader) LastEventID() string { return r.lastID }
+
+// RetryDelay returns the last reconnection delay announced by the server.
+func (r *Reader) RetryDelay() time.Duration { return r.retry }
+
+// Next returns the next event carrying data. It returns io.EOF once the
+// stream ends; a trailing event without a blank line terminator is dispatched.
+func (r *Reader) Next() (Event, error) {
+	var (
+		ev      Event
+		data    bytes.Buffer
+		hasData bool
+	)
+
+	for {
+		line, err := r.readLine()
+		if err != nil && err != io.EOF {
+			return Event{}, err
+		}
+		eof := err == io.EOF
+
+		switch {
+		case line == "":
+			if hasData {
+				return r.dispatch(ev, data.String()), nil
+			}
+			// A blank line without data discards the pending event fields.
+			ev = Event{}
+		case line[0] == ':':
+			// Comment / heartbeat line.
+		default:
+			field, value := line, ""
+			if i := strings.IndexByte(line, ':'); i >= 0 {
+				field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+			}
+			switch field {
+			case "data":
+				if hasData {
+					data.WriteByte('\n')
+				}
+				data.WriteString(value)
+				hasData = true
+			case "event":
+				ev.Event = value
+			case "id":
+				if !strings.ContainsRune(value, 0) {
+					ev.ID = value
+					r.lastID = value
+				}
+			case "retry":
+				if ms, convErr := strconv.Atoi(value); convErr == nil && ms >= 0 {
+					r.retry = time.Duration(ms) * time.Millisecond
+					ev.Retry = r.retry
+				}
+			}
+		}
+
+		if eof {
+			if hasData {
+				return r.dispatch(ev, data.String()), nil
+			}
+			return Event{}, io.EOF
+		}
+	}
+}
+
+func (r *Reader) dispatch(ev Event, data string) Event {
+	ev.Data = data
+	if ev.ID == "" {
+		ev.ID = r.lastID
+	}
+	return ev
+}
+
+// readLine reads a full line of any length, accepting "\n", "\r\n" or "\r" endings.
+func (r *Reader) readLine() (string, error) {
+	var buf bytes.Buffer
+	for {
+		b, err := r.r.ReadByte()
+		if err != nil {
+			return buf.String(), err
+		}
+		switch b {
+		case '\n':
+			return buf.String(), nil
+		case '\r':
+			if next, err := r.r.Peek(1); err == nil && next[0] == '\n' {
+				_, _ = r.r.ReadByte()
+			}
+			return buf.String(), nil
+		default:
+			buf.WriteByte(b)
+		}
+	}
+}
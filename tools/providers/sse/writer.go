@@ -0,0 +1,183 @@
+package sse
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	kbxTypes "github.com/kubex-ecosystem/kbx/types"
+)
+
+// DefaultHeartbeat is the idle interval after which StreamChunks emits a comment
+// line so proxies and load balancers keep the connection open.
+const DefaultHeartbeat = 15 * time.Second
+
+var ErrFlushUnsupported = errors.New("sse: response writer does not support flushing")
+
+// Writer encodes events to an http.ResponseWriter and flushes after each one.
+// It is safe for concurrent use.
+type Writer struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+	started bool
+}
+
+// NewWriter returns a Writer for w, which must implement http.Flusher.
+func NewWriter(w http.ResponseWriter) (*Writer, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, ErrFlushUnsupported
+	}
+	return &Writer{w: w, flusher: flusher}, nil
+}
+
+// WriteEvent writes a single event. Multi-line data is split into several data fields.
+func (sw *Writer) WriteEvent(ev Event) error {
+	var b strings.Builder
+	if ev.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", ev.ID)
+	}
+	if ev.Event != "" {
+		fmt.Fprintf(&b, "event: %s\n", ev.Event)
+	}
+	if ev.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %d\n", ev.Retry.Milliseconds())
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(ev.Data, "\r\n", "\n"), "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteByte('\n')
+	return sw.write(b.String())
+}
+
+// WriteJSON marshals v as the data of an event with the given name.
+func (sw *Writer) WriteJSON(event string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return sw.WriteEvent(Event{Event: event, Data: string(data)})
+}
+
+// Comment writes a comment line, used as heartbeat.
+func (sw *Writer) Comment(text string) error {
+	return sw.write(": " + strings.ReplaceAll(text, "\n", " ") + "\n\n")
+}
+
+func (sw *Writer) write(s string) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if !sw.started {
+		h := sw.w.Header()
+		h.Set("Content-Type", "text/event-stream")
+		h.Set("Cache-Control", "no-cache")
+		h.Set("Connection", "keep-alive")
+		h.Set("X-Accel-Buffering", "no")
+		sw.w.WriteHeader(http.StatusOK)
+		sw.started = true
+	}
+	if _, err := fmt.Fprint(sw.w, s); err != nil {
+		return err
+	}
+	sw.flusher.Flush()
+	return nil
+}
+
+// StreamOptions customizes how StreamChunks encodes a ChatChunk stream.
+type StreamOptions struct {
+	// Heartbeat is the idle interval between comment lines. Zero uses
+	// DefaultHeartbeat, a negative value disables heartbeats.
+	Heartbeat time.Duration
+	// Encode maps a chunk to zero or more events. When nil each chunk is sent as
+	// JSON in a "chunk" event and errors in an "error" event.
+	Encode func(kbxTypes.ChatChunk) []Event
+	// Done is the data of the terminating event. Empty uses DoneSentinel.
+	Done string
+}
+
+// StreamChunks writes every chunk from ch as SSE until the channel closes, the
+// context is cancelled or a chunk carries an error. The stream always ends with
+// the Done event, except when the client goes away. The channel is drained on
+// early return so the producing goroutine is never blocked.
+func StreamChunks(ctx context.Context, w http.ResponseWriter, ch <-chan kbxTypes.ChatChunk, opts StreamOptions) error {
+	defer drain(ch)
+	sw, err := NewWriter(w)
+	if err != nil {
+		return err
+	}
+
+	encode := opts.Encode
+	if encode == nil {
+		encode = defaultEncode
+	}
+	done := opts.Done
+	if done == "" {
+		done = DoneSentinel
+	}
+	heartbeat := opts.Heartbeat
+	if heartbeat == 0 {
+		heartbeat = DefaultHeartbeat
+	}
+
+	// The heartbeat restarts after every event, so it only fires on idle streams.
+	var tick <-chan time.Time
+	idle := func() {}
+	if heartbeat > 0 {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+		idle = func() { ticker.Reset(heartbeat) }
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick:
+			if err := sw.Comment("keep-alive"); err != nil {
+				return err
+			}
+		case chunk, open := <-ch:
+			if !open {
+				return sw.WriteEvent(Event{Data: done})
+			}
+			events := encode(chunk)
+			for _, ev := range events {
+				if err := sw.WriteEvent(ev); err != nil {
+					return err
+				}
+			}
+			if len(events) > 0 {
+				idle()
+			}
+			if chunk.IsError() {
+				return sw.WriteEvent(Event{Data: done})
+			}
+		}
+	}
+}
+
+func defaultEncode(chunk kbxTypes.ChatChunk) []Event {
+	name := "chunk"
+	if chunk.IsError() {
+		name = "error"
+	}
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return nil
+	}
+	return []Event{{Event: name, Data: string(data)}}
+}
+
+func drain(ch <-chan kbxTypes.ChatChunk) {
+	go func() {
+		for range ch {
+		}
+	}()
+}
@@ -0,0 +1,244 @@
+package registry
+
+import (
+	"context"
+	"io"
+	"slices"
+	"strings"
+
+	kbxTypes "github.com/kubex-ecosystem/kbx/types"
+
+	gl "github.com/kubex-ecosystem/logz"
+)
+
+// tenantID returns the request's tenant, falling back to the configured default tenant.
+func (r *Registry) tenantID(req kbxTypes.ChatRequest) string {
+	if tenant := tenantOf(req); tenant != "" {
+		return tenant
+	}
+	if r == nil || r.cfg == nil {
+		return ""
+	}
+	return strings.TrimSpace(r.cfg.Development.Defaults.TenantID)
+}
+
+// tenantConfig returns the overlay for tenant, or nil when it has none.
+func (r *Registry) tenantConfig(tenant string) *kbxTypes.LLMTenantConfig {
+	if r == nil || r.cfg == nil || tenant == "" {
+		return nil
+	}
+	return r.cfg.Tenants[tenant]
+}
+
+// tenantAllows reports whether the provider is enabled for the tenant.
+func tenantAllows(tc *kbxTypes.LLMTenantConfig, name string) bool {
+	if tc == nil {
+		return true
+	}
+	tpc, listed := tc.Providers[name]
+	if !listed {
+		return !tc.Restrict
+	}
+	return tpc == nil || tpc.Enabled == nil || *tpc.Enabled
+}
+
+// tenantAllowsModel checks model against the tenant's allow-list for provider
+// name, if it has one, and returns the model checked. An empty model means the
+// provider's default, which the allow-list covers too.
+func (r *Registry) tenantAllowsModel(tpc *kbxTypes.LLMTenantProviderConfig, name, model string) (string, bool) {
+	if tpc == nil || len(tpc.Models) == 0 {
+		return model, true
+	}
+	model = strings.TrimSpace(model)
+	if model == "" {
+		model = strings.TrimSpace(tpc.DefaultModel)
+	}
+	if model == "" {
+		if pc := r.GetProviderConfig(name); pc != nil {
+			model = strings.TrimSpace(pc.DefaultModel)
+		}
+	}
+	return model, slices.Contains(tpc.Models, model)
+}
+
+// tenantMayShadow reports whether req may be mirrored to the shared instance
+// of its provider: the tenant must allow the provider and model, and must not
+// use its own key for the provider.
+func (r *Registry) tenantMayShadow(tenant string, req kbxTypes.ChatRequest) bool {
+	tc := r.tenantConfig(tenant)
+	if tc == nil {
+		return true
+	}
+	name := normalizeProviderName(req.Provider)
+	if !tenantAllows(tc, name) {
+		return false
+	}
+	tpc := tc.Providers[name]
+	if tpc != nil && strings.TrimSpace(tpc.KeyEnv) != "" {
+		return false
+	}
+	_, ok := r.tenantAllowsModel(tpc, name, req.Model)
+	return ok
+}
+
+// applyTenantDefaults fills the provider and model from the tenant overlay and
+// caps MaxTokens at the tenant's limit.
+func (r *Registry) applyTenantDefaults(req kbxTypes.ChatRequest) kbxTypes.ChatRequest {
+	tc := r.tenantConfig(r.tenantID(req))
+	if tc == nil {
+		return req
+	}
+	if strings.TrimSpace(req.Provider) == "" {
+		req.Provider = tc.DefaultProvider
+	}
+	if tpc := tc.Providers[normalizeProviderName(req.Provider)]; tpc != nil && req.Model == "" {
+		req.Model = tpc.DefaultModel
+	}
+	if tc.MaxTokens > 0 && (req.MaxTokens == 0 || req.MaxTokens > tc.MaxTokens) {
+		req.MaxTokens = tc.MaxTokens
+	}
+	return req
+}
+
+// resolveForTenant returns the provider serving req for tenant. Providers with
+// a tenant key resolve to that tenant's own instance; if the key cannot be
+// used the request fails rather than falling back to the shared key.
+func (r *Registry) resolveForTenant(tenant string, req kbxTypes.ChatRequest) (kbxTypes.ProviderExt, error) {
+	name := normalizeProviderName(req.Provider)
+	tc := r.tenantConfig(tenant)
+	if tc == nil {
+		if p := r.ResolveProvider(name); p != nil {
+			return p, nil
+		}
+		return nil, gl.Errorf("%w: '%s'", ErrProviderNotFound, req.Provider)
+	}
+
+	if !tenantAllows(tc, name) {
+		return nil, gl.Errorf("%w: provider '%s' is not enabled for tenant '%s'", ErrInvalidRequest, req.Provider, tenant)
+	}
+	tpc := tc.Providers[name]
+	if model, ok := r.tenantAllowsModel(tpc, name, req.Model); !ok {
+		return nil, gl.Errorf("%w: model '%s' of provider '%s' is not enabled for tenant '%s'", ErrInvalidRequest, model, req.Provider, tenant)
+	}
+	if tpc == nil || strings.TrimSpace(tpc.KeyEnv) == "" {
+		if p := r.ResolveProvider(name); p != nil {
+			return p, nil
+		}
+		return nil, gl.Errorf("%w: '%s'", ErrProviderNotFound, req.Provider)
+	}
+	return r.tenantProvider(tenant, name, tpc)
+}
+
+// tenantProvider returns (building it on first use) the tenant's own instance of provider name.
+func (r *Registry) tenantProvider(tenant, name string, tpc *kbxTypes.LLMTenantProviderConfig) (kbxTypes.ProviderExt, error) {
+	r.mu.RLock()
+	p, ok := r.tenantProviders[tenant][name]
+	r.mu.RUnlock()
+	if ok {
+		return p, nil
+	}
+
+	pc := r.GetProviderConfig(name)
+	if pc == nil {
+		return nil, gl.Errorf("provider '%s' is not configured", name)
+	}
+	providerType := normalizeProviderType(name, pc)
+	constructor, ok := providerConstructors[providerType]
+	if !ok {
+		return nil, gl.Errorf("provider '%s' has unsupported type '%s'", name, providerType)
+	}
+	key := resolveCandidateValue(tpc.KeyEnv)
+	if key == "" {
+		return nil, gl.Errorf("API key for provider '%s' of tenant '%s' could not be resolved", name, tenant)
+	}
+	model := strings.TrimSpace(tpc.DefaultModel)
+	if model == "" {
+		model = strings.TrimSpace(pc.DefaultModel)
+	}
+	p, err := constructor(name, strings.TrimSpace(pc.BaseURL), key, model)
+	if err != nil {
+		return nil, gl.Errorf("failed to initialize provider '%s' for tenant '%s' with key %s: %v", name, tenant, maskKey(tpc.KeyEnv, key), err)
+	}
+	if mock, ok := p.(*MockProvider); ok && pc.Mock != nil {
+		if err := mock.SetScript(*pc.Mock); err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.tenantProviders[tenant][name]; ok {
+		if closer, ok := p.(io.Closer); ok {
+			_ = closer.Close()
+		}
+		return existing, nil
+	}
+	if r.tenantProviders == nil {
+		r.tenantProviders = make(map[string]map[string]kbxTypes.ProviderExt)
+	}
+	if r.tenantProviders[tenant] == nil {
+		r.tenantProviders[tenant] = make(map[string]kbxTypes.ProviderExt)
+	}
+	r.tenantProviders[tenant][name] = p
+	gl.Debugf("Provider '%s' initialized with the own key of tenant '%s'", name, tenant)
+	return p, nil
+}
+
+// ResetTenant drops the tenant's own provider instances and their breakers, so
+// rotated keys are picked up on the next request.
+func (r *Registry) ResetTenant(tenant string) {
+	r.mu.Lock()
+	providers := r.tenantProviders[tenant]
+	delete(r.tenantProviders, tenant)
+	for name := range providers {
+		delete(r.breakers, tenantBreakerKey(tenant, name))
+	}
+	r.mu.Unlock()
+	for name, p := range providers {
+		closer, ok := p.(io.Closer)
+		if !ok {
+			continue
+		}
+		if err := closer.Close(); err != nil {
+			gl.Warnf("Failed to close provider '%s' of tenant '%s': %v", name, tenant, err)
+		}
+	}
+}
+
+// waitTenantRateLimit blocks on the tenant's own token bucket, if it has one.
+func (r *Registry) waitTenantRateLimit(ctx context.Context, tenant string) error {
+	tc := r.tenantConfig(tenant)
+	if tc == nil || tc.RateLimit == nil {
+		return nil
+	}
+	key := "tenant:" + tenant
+
+	r.mu.Lock()
+	if r.limiters == nil {
+		r.limiters = make(map[string]*tokenBucket)
+	}
+	bucket, ok := r.limiters[key]
+	if !ok {
+		bucket = newTokenBucket(*tc.RateLimit)
+		r.limiters[key] = bucket
+	}
+	r.mu.Unlock()
+
+	return bucket.Wait(ctx)
+}
+
+// normalizeTenantConfig lower-cases provider names so they match the base config.
+func normalizeTenantConfig(tc *kbxTypes.LLMTenantConfig) *kbxTypes.LLMTenantConfig {
+	if tc == nil {
+		return nil
+	}
+	out := *tc
+	out.DefaultProvider = normalizeProviderName(tc.DefaultProvider)
+	if len(tc.Providers) > 0 {
+		out.Providers = make(map[string]*kbxTypes.LLMTenantProviderConfig, len(tc.Providers))
+		for name, tpc := range tc.Providers {
+			out.Providers[normalizeProviderName(name)] = tpc
+		}
+	}
+	return &out
+}
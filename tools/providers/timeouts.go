@@ -0,0 +1,222 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	kbxTypes "github.com/kubex-ecosystem/kbx/types"
+)
+
+// Transport-level bounds of the adapters' HTTP client. They apply even when an
+// adapter is called directly, outside Registry.Chat and watchTimeouts.
+const (
+	clientDialTimeout           = 30 * time.Second
+	clientTLSHandshakeTimeout   = 10 * time.Second
+	clientResponseHeaderTimeout = 2 * time.Minute
+)
+
+// newStreamingClient returns the HTTP client used by the REST adapters. It has
+// no overall Timeout, which would cut off long streams; its transport bounds
+// dialing, the TLS handshake and the wait for response headers, and the
+// registry bounds the connect, first-token and idle phases of each request on
+// top of that (see watchTimeouts).
+func newStreamingClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: clientDialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = clientTLSHandshakeTimeout
+	transport.ResponseHeaderTimeout = clientResponseHeaderTimeout
+	return &http.Client{Transport: transport}
+}
+
+// resolveTimeouts merges, phase by phase, the request's timeouts with the
+// provider's production config (by name, then type) and the request defaults.
+// Within each config level a phase falls back to its TimeoutSec.
+func (r *Registry) resolveTimeouts(name string, req kbxTypes.ChatRequest) kbxTypes.ChatTimeouts {
+	t := kbxTypes.ChatTimeouts{}
+	if req.Timeouts != nil {
+		t = *req.Timeouts
+	}
+	if r == nil || r.cfg == nil {
+		return t
+	}
+
+	levels := [][4]int{}
+	for _, key := range []string{normalizeProviderName(name), normalizeProviderType(name, r.GetProviderConfig(name))} {
+		if pc, ok := r.providerProduction(key); ok {
+			levels = append(levels, [4]int{pc.ConnectTimeoutSec, pc.FirstTokenTimeoutSec, pc.IdleTimeoutSec, pc.TimeoutSec})
+			break
+		}
+	}
+	d := r.cfg.Development.Defaults
+	levels = append(levels, [4]int{d.ConnectTimeoutSec, d.FirstTokenTimeoutSec, d.IdleTimeoutSec, d.TimeoutSec})
+
+	fill := func(phase *time.Duration, idx int) {
+		for _, level := range levels {
+			if *phase > 0 {
+				return
+			}
+			sec := level[idx]
+			if sec <= 0 {
+				sec = level[3]
+			}
+			*phase = time.Duration(sec) * time.Second
+		}
+	}
+	fill(&t.Connect, 0)
+	fill(&t.FirstToken, 1)
+	fill(&t.Idle, 2)
+	return t
+}
+
+// providerProduction looks up the production config of a provider, matching
+// config keys the way ResolveProvider matches names.
+func (r *Registry) providerProduction(name string) (kbxTypes.LLMProviderProductionConfig, bool) {
+	if pc, ok := r.cfg.ProviderProduction[name]; ok {
+		return pc, true
+	}
+	for key, pc := range r.cfg.ProviderProduction {
+		if normalizeProviderName(key) == name {
+			return pc, true
+		}
+	}
+	return kbxTypes.LLMProviderProductionConfig{}, false
+}
+
+// watchTimeouts calls p.Chat and enforces t on the resulting stream. When a
+// phase runs out, the provider call is cancelled and the stream ends with a
+// chunk carrying a *TimeoutError. Expiry of the caller's deadline is reported
+// the same way, with the TimeoutDeadline phase.
+func watchTimeouts(ctx context.Context, p kbxTypes.ProviderExt, req kbxTypes.ChatRequest, t kbxTypes.ChatTimeouts) (<-chan kbxTypes.ChatChunk, error) {
+	if _, hasDeadline := ctx.Deadline(); t.IsZero() && !hasDeadline {
+		return p.Chat(ctx, req)
+	}
+
+	callCtx, cancel := context.WithCancel(ctx)
+	connected := make(chan struct{})
+	var connectedOnce sync.Once
+	callCtx = httptrace.WithClientTrace(callCtx, &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) { connectedOnce.Do(func() { close(connected) }) },
+	})
+
+	start := time.Now()
+	in, err := p.Chat(callCtx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	out := make(chan kbxTypes.ChatChunk, cap(in))
+	go func() {
+		defer close(out)
+		defer cancel()
+
+		timeoutChunk := func(phase kbxTypes.TimeoutPhase) kbxTypes.ChatChunk {
+			terr := &kbxTypes.TimeoutError{
+				Phase:    phase,
+				AfterMs:  time.Since(start).Milliseconds(),
+				Provider: p.Name(),
+				Model:    req.Model,
+			}
+			return kbxTypes.ChatChunk{Done: true, Error: terr.Error(), Timeout: terr}
+		}
+		// abort cancels the provider call, lets it wind down in the background
+		// and ends the stream with final.
+		abort := func(final kbxTypes.ChatChunk) {
+			cancel()
+			go func() {
+				for range in {
+				}
+			}()
+			select {
+			case out <- final:
+			case <-time.After(time.Second):
+			}
+		}
+
+		timer := time.NewTimer(time.Hour)
+		timer.Stop()
+		var timerC <-chan time.Time
+		var phase kbxTypes.TimeoutPhase
+		connecting, streaming := true, false
+		// rearm points the timer at the nearest pending limit. Connect and
+		// first-token limits are measured from the start of the request, idle
+		// from the last chunk.
+		rearm := func() {
+			timer.Stop()
+			timerC = nil
+			if streaming {
+				if t.Idle > 0 {
+					phase = kbxTypes.TimeoutIdle
+					timer.Reset(t.Idle)
+					timerC = timer.C
+				}
+				return
+			}
+			var next time.Duration
+			if connecting && t.Connect > 0 {
+				phase, next = kbxTypes.TimeoutConnect, t.Connect
+			}
+			if t.FirstToken > 0 && (next == 0 || t.FirstToken < next) {
+				phase, next = kbxTypes.TimeoutFirstToken, t.FirstToken
+			}
+			if next > 0 {
+				timer.Reset(max(next-time.Since(start), time.Nanosecond))
+				timerC = timer.C
+			}
+		}
+		rearm()
+		connectedC := (<-chan struct{})(connected)
+
+		for {
+			select {
+			case <-connectedC:
+				connectedC, connecting = nil, false
+				rearm()
+
+			case chunk, ok := <-in:
+				if !ok {
+					return
+				}
+				// Any chunk proves the connection, including from non-HTTP providers.
+				connectedC, connecting = nil, false
+				if chunk.HasContent() || chunk.HasReasoning() || chunk.HasToolCall() {
+					streaming = true
+				}
+				rearm()
+				if chunk.IsError() && chunk.Timeout == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					chunk.Timeout = timeoutChunk(kbxTypes.TimeoutDeadline).Timeout
+					chunk.Error = chunk.Timeout.Error()
+				}
+				select {
+				case out <- chunk:
+				case <-ctx.Done():
+					abort(contextEndChunk(ctx, timeoutChunk))
+					return
+				}
+
+			case <-timerC:
+				abort(timeoutChunk(phase))
+				return
+
+			case <-ctx.Done():
+				abort(contextEndChunk(ctx, timeoutChunk))
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// contextEndChunk returns the deadline chunk when ctx expired, or a plain
+// cancellation chunk otherwise.
+func contextEndChunk(ctx context.Context, timeoutChunk func(kbxTypes.TimeoutPhase) kbxTypes.ChatChunk) kbxTypes.ChatChunk {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return timeoutChunk(kbxTypes.TimeoutDeadline)
+	}
+	return kbxTypes.ChatChunk{Done: true, Error: ctx.Err().Error()}
+}
@@ -8,6 +8,41 @@ import (
 
 var ErrRetryExhausted = errors.New("retry limits exhausted")
 
+// PermanentError marks an error that Retry must not retry.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// Permanent wraps err so Retry returns it at once instead of trying again.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// RetryAfterError marks an error whose next attempt must wait Delay, e.g. the
+// Retry-After of a rate-limit response, instead of the backoff delay.
+type RetryAfterError struct {
+	Err   error
+	Delay time.Duration
+}
+
+func (e *RetryAfterError) Error() string { return e.Err.Error() }
+func (e *RetryAfterError) Unwrap() error { return e.Err }
+
+// RetryAfter wraps err so Retry waits d before the next attempt. The wait
+// replaces the backoff delay, which does not grow for that attempt.
+func RetryAfter(err error, d time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &RetryAfterError{Err: err, Delay: d}
+}
+
 type Retryer struct {
 	cfg RetryConfig
 }
@@ -49,6 +84,7 @@ type RetryStats struct {
 	MaxAttempts int
 }
 type RetryOption struct {
+	Context       context.Context
 	Retries       int
 	Delay         time.Duration
 	Timeout       time.Duration
@@ -59,6 +95,7 @@ type RetryOption struct {
 }
 
 type RetryConfig struct {
+	Context       context.Context
 	Retries       int
 	Delay         time.Duration
 	Timeout       time.Duration
@@ -95,6 +132,11 @@ func WithTimeout(d time.Duration) *RetryOption {
 	return &RetryOption{Timeout: d}
 }
 
+// WithContext makes Retry stop, waits included, as soon as ctx ends.
+func WithContext(ctx context.Context) *RetryOption {
+	return &RetryOption{Context: ctx}
+}
+
 // Retry unifica TUDO. Se for Void, basta usar Retry[struct{}] e ignorar o retorno.
 func Retry[T any](fn func() (T, error), opts ...*RetryOption) (T, error) {
 	// Configuração Default
@@ -104,6 +146,9 @@ func Retry[T any](fn func() (T, error), opts ...*RetryOption) (T, error) {
 		Timeout: 0,
 	}
 	for _, opt := range opts {
+		if opt.Context != nil {
+			config.Context = opt.Context
+		}
 		if opt.Retries > 0 {
 			config.Retries = opt.Retries
 		}
@@ -113,29 +158,35 @@ func Retry[T any](fn func() (T, error), opts ...*RetryOption) (T, error) {
 		if opt.Timeout > 0 {
 			config.Timeout = opt.Timeout
 		}
-		// if opt.MaxDelay > 0 {
-		// 	config.MaxDelay = opt.MaxDelay
-		// }
-		// if opt.InitialDelay > 0 {
-		// 	config.InitialDelay = opt.InitialDelay
-		// }
-		// if opt.BackoffFactor > 0 {
-		// 	config.BackoffFactor = opt.BackoffFactor
-		// }
+		if opt.MaxDelay > 0 {
+			config.MaxDelay = opt.MaxDelay
+		}
+		if opt.InitialDelay > 0 {
+			config.InitialDelay = opt.InitialDelay
+		}
+		if opt.BackoffFactor > 0 {
+			config.BackoffFactor = opt.BackoffFactor
+		}
+	}
+	// Backoff: começa em InitialDelay (ou Delay), multiplica por BackoffFactor
+	// a cada tentativa e para em MaxDelay.
+	delay := config.Delay
+	if config.InitialDelay > 0 {
+		delay = config.InitialDelay
 	}
 
 	var lastErr error
 	var result T
 
 	// Controle de Timeout Global via Contexto (Jeito Go moderno)
-	var ctx context.Context
-	var cancel context.CancelFunc
-
+	ctx := config.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	if config.Timeout > 0 {
-		ctx, cancel = context.WithTimeout(context.Background(), config.Timeout)
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
 		defer cancel()
-	} else {
-		ctx = context.Background()
 	}
 
 	for i := 0; i < config.Retries; i++ {
@@ -151,16 +202,40 @@ func Retry[T any](fn func() (T, error), opts ...*RetryOption) (T, error) {
 		if lastErr == nil {
 			return result, nil
 		}
+		var permanent *PermanentError
+		if errors.As(lastErr, &permanent) {
+			return result, permanent.Err
+		}
+
+		// Quem pediu uma espera (RetryAfter) dita o tempo, sem avançar o backoff
+		var after *RetryAfterError
+		if errors.As(lastErr, &after) {
+			lastErr = after.Err
+			if i < config.Retries-1 && after.Delay > 0 {
+				select {
+				case <-ctx.Done():
+					return result, ctx.Err()
+				case <-time.After(after.Delay):
+				}
+			}
+			continue
+		}
 
 		// Se não for a última tentativa, espera
-		if i < config.Retries-1 && config.Delay > 0 {
+		if i < config.Retries-1 && delay > 0 {
 			// Sleep respeitando o contexto (se cancelar no meio do sleep, acorda)
 			select {
 			case <-ctx.Done():
 				return result, ctx.Err()
-			case <-time.After(config.Delay):
+			case <-time.After(delay):
 				// continua
 			}
+			if config.BackoffFactor > 1 {
+				delay = time.Duration(float64(delay) * config.BackoffFactor)
+				if config.MaxDelay > 0 && delay > config.MaxDelay {
+					delay = config.MaxDelay
+				}
+			}
 		}
 	}
 
@@ -3,6 +3,7 @@ package crypto
 
 import (
 	"bytes"
+	"crypto/cipher"
 	"crypto/rand"
 	"encoding/base64"
 	"math/big"
@@ -220,6 +221,62 @@ func (s *CryptoService) Decrypt(encrypted []byte, key []byte) (string, string, e
 	return string(decrypted), encoded, nil
 }
 
+// EncryptBytes seals data with XChaCha20-Poly1305 and returns nonce+ciphertext
+// as unpadded Base64 URL. Unlike Encrypt, the input is taken verbatim: it is
+// never decoded, trimmed or assumed to be encrypted already, so arbitrary
+// payloads round-trip exactly through DecryptBytes.
+// The key is either 32 raw bytes (as from GenerateKey) or their Base64 URL form.
+func (s *CryptoService) EncryptBytes(data []byte, key []byte) (string, error) {
+	aead, err := newSealer(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", gl.Errorf("failed to generate nonce: %v", err)
+	}
+	sealed := aead.Seal(nonce, nonce, data, nil)
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptBytes opens a payload produced by EncryptBytes with the same key.
+func (s *CryptoService) DecryptBytes(encrypted string, key []byte) ([]byte, error) {
+	aead, err := newSealer(key)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(encrypted))
+	if err != nil {
+		return nil, gl.Errorf("failed to decode data: %v", err)
+	}
+	if len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return nil, gl.Error("encrypted payload too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, gl.Errorf("failed to decrypt data: %v", err)
+	}
+	return plain, nil
+}
+
+// newSealer builds the AEAD for EncryptBytes/DecryptBytes from a raw or Base64 URL key.
+func newSealer(key []byte) (cipher.AEAD, error) {
+	raw := bytes.TrimSpace(key)
+	if len(raw) != chacha20poly1305.KeySize {
+		decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(string(raw), "="))
+		if err != nil || len(decoded) != chacha20poly1305.KeySize {
+			return nil, gl.Errorf("invalid encryption key: expected %d bytes", chacha20poly1305.KeySize)
+		}
+		raw = decoded
+	}
+	aead, err := chacha20poly1305.NewX(raw)
+	if err != nil {
+		return nil, gl.Errorf("failed to create cipher: %v", err)
+	}
+	return aead, nil
+}
+
 // GenerateKey generates a random key of the specified length using the crypto/rand package
 // It uses a character set of alphanumeric characters to generate the key
 // The generated key is returned as a byte slice
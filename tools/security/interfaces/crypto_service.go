@@ -10,6 +10,10 @@ type ICryptoService interface {
 	// It ensures the data is decoded before decryption and the key is valid
 	// Decrypt(encryptedData string, nonce string, key []byte) (decryptedData string, err error)
 	Decrypt([]byte, []byte) (string, string, error)
+	// EncryptBytes seals arbitrary bytes verbatim and returns them as unpadded Base64 URL
+	EncryptBytes([]byte, []byte) (string, error)
+	// DecryptBytes opens a payload produced by EncryptBytes
+	DecryptBytes(string, []byte) ([]byte, error)
 
 	// GenerateKey generates a random key of default length (32 bytes for ChaCha20-Poly1305)
 	GenerateKey() ([]byte, error)
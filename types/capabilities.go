@@ -0,0 +1,44 @@
+package types
+
+// ModelCapabilities describes what a model supports. As a requirement
+// (ChatRequest.Requires), true flags are mandatory and non-zero limits are minimums.
+type ModelCapabilities struct {
+	Streaming       bool `yaml:"streaming,omitempty" json:"streaming,omitempty" mapstructure:"streaming,omitempty"`
+	Tools           bool `yaml:"tools,omitempty" json:"tools,omitempty" mapstructure:"tools,omitempty"`
+	Vision          bool `yaml:"vision,omitempty" json:"vision,omitempty" mapstructure:"vision,omitempty"`
+	JSONMode        bool `yaml:"json_mode,omitempty" json:"json_mode,omitempty" mapstructure:"json_mode,omitempty"`
+	ContextWindow   int  `yaml:"context_window,omitempty" json:"context_window,omitempty" mapstructure:"context_window,omitempty"`
+	MaxOutputTokens int  `yaml:"max_output_tokens,omitempty" json:"max_output_tokens,omitempty" mapstructure:"max_output_tokens,omitempty"`
+	// Reasoning models think before answering and take a reasoning effort
+	// instead of sampling parameters (temperature, top_p).
+	Reasoning bool `yaml:"reasoning,omitempty" json:"reasoning,omitempty" mapstructure:"reasoning,omitempty"`
+}
+
+// Satisfies reports whether c meets every requirement in req.
+func (c ModelCapabilities) Satisfies(req ModelCapabilities) bool {
+	switch {
+	case req.Streaming && !c.Streaming,
+		req.Tools && !c.Tools,
+		req.Vision && !c.Vision,
+		req.JSONMode && !c.JSONMode,
+		req.Reasoning && !c.Reasoning,
+		req.ContextWindow > c.ContextWindow,
+		req.MaxOutputTokens > c.MaxOutputTokens:
+		return false
+	}
+	return true
+}
+
+// CapabilitiesFromInfo extracts the descriptor stored under "capabilities" by
+// ProviderExt.ModelInfo implementations.
+func CapabilitiesFromInfo(info map[string]any) (ModelCapabilities, bool) {
+	switch c := info["capabilities"].(type) {
+	case ModelCapabilities:
+		return c, true
+	case *ModelCapabilities:
+		if c != nil {
+			return *c, true
+		}
+	}
+	return ModelCapabilities{}, false
+}
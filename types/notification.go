@@ -3,6 +3,7 @@ package types
 import (
 	"context"
 	"reflect"
+	"sort"
 	"sync"
 	"time"
 
@@ -43,7 +44,7 @@ type NotifierEventExt[P NotifierConfig[P] | LLMProviderConfig | ChatRequest | LL
 
 	Wait(ctx context.Context) error
 	WaitWithTimeout(ctx context.Context, timeout time.Duration) error
-	WaitWithCondition(ctx context.Context, cond sync.Cond) error
+	WaitWithCondition(ctx context.Context, cond *sync.Cond) error
 
 	Timeout(context.Context) time.Duration
 }
@@ -72,13 +73,16 @@ type NotifierConfig[P NotifierConfig[P] | LLMProviderConfig | ChatRequest | LLMC
 	Parameters map[string]any `yaml:"parameters"` // provider-specific parameters
 }
 
-// NotifierRegistry holds registered notifier providers
+// NotifierRegistry holds registered notifier providers. It is safe for concurrent use.
 type NotifierRegistry struct {
+	mu        sync.RWMutex
 	providers map[string]NotifierProvider
 }
 
 // Register adds a new notifier provider to the registry
 func (r *NotifierRegistry) Register(provider NotifierProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if r.providers == nil {
 		r.providers = make(map[string]NotifierProvider)
 	}
@@ -87,23 +91,36 @@ func (r *NotifierRegistry) Register(provider NotifierProvider) {
 
 // Notify sends a notification using the appropriate provider based on the event type
 func (r *NotifierRegistry) Notify(ctx context.Context, event NotifierEvent[any]) error {
-	provider, exists := r.providers[event.Type(ctx)]
+	provider, exists := r.GetProvider(event.Type(ctx))
 	if !exists {
 		return gl.Errorf("no notifier provider registered for type '%s'", event.Type(ctx))
 	}
 	return provider.Notify(ctx, event)
 }
 
+// Dispatch starts delivering cfg through the provider registered for cfg.Type
+// and returns the notification, to be awaited or cancelled by the caller.
+func (r *NotifierRegistry) Dispatch(ctx context.Context, cfg NotifierConfig[any]) *Notification[any] {
+	n := NewNotification(cfg, r.Notify)
+	n.Dispatch(ctx)
+	return n
+}
+
 // ListProviders returns the names of all registered notifier providers
 func (r *NotifierRegistry) ListProviders() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	providers := make([]string, 0, len(r.providers))
 	for name := range r.providers {
 		providers = append(providers, name)
 	}
+	sort.Strings(providers)
 	return providers
 }
 
 func (r *NotifierRegistry) GetProvider(name string) (NotifierProvider, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	provider, exists := r.providers[name]
 	return provider, exists
 }
@@ -118,3 +135,23 @@ type NotificationEvent struct {
 	Metadata  map[string]any `json:"metadata"`
 	CreatedAt time.Time      `json:"created_at"`
 }
+
+// Config converts e into the NotifierConfig backing a Notification.
+func (e NotificationEvent) Config() NotifierConfig[any] {
+	return NotifierConfig[any]{
+		Type:      e.Type,
+		Recipient: e.Recipient,
+		Subject:   e.Subject,
+		Content:   e.Content,
+		Priority:  e.Priority,
+		Metadata:  e.Metadata,
+		CreatedAt: e.CreatedAt,
+	}
+}
+
+// AsNotifierEvent wraps e so it can be sent through a NotifierRegistry. The
+// result has no sender of its own; use NotifierRegistry.Dispatch to deliver
+// and await it.
+func (e NotificationEvent) AsNotifierEvent() NotifierEvent[any] {
+	return NewNotification(e.Config(), nil)
+}
@@ -0,0 +1,283 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	gl "github.com/kubex-ecosystem/logz"
+)
+
+// DefaultNotificationTimeout bounds one delivery attempt when the config sets none.
+const DefaultNotificationTimeout = 30 * time.Second
+
+var (
+	// ErrNotificationCanceled is the error of a notification cancelled before it was delivered.
+	ErrNotificationCanceled = errors.New("notification canceled")
+	// ErrNoNotificationSender is returned by Dispatch on a notification built without a sender.
+	ErrNoNotificationSender = errors.New("notification has no sender")
+)
+
+var _ NotifierEventExt[any] = (*Notification[any])(nil)
+
+// NotificationSender delivers a notification, e.g. NotifierProvider.Notify.
+type NotificationSender[P NotifierConfig[P] | LLMProviderConfig | ChatRequest | LLMConfig | any] func(ctx context.Context, event NotifierEvent[P]) error
+
+// Notification is the standard NotifierEventExt, backed by a NotifierConfig.
+// One Notification is one delivery attempt: Dispatch runs it once (later calls
+// report the same outcome), Cancel stops it, and Retry yields a new attempt.
+// All methods are safe for concurrent use.
+type Notification[P NotifierConfig[P] | LLMProviderConfig | ChatRequest | LLMConfig | any] struct {
+	ref     GlobalRef
+	cfg     NotifierConfig[P]
+	send    NotificationSender[P]
+	timeout time.Duration
+	attempt int
+
+	mu         sync.Mutex
+	dispatched bool
+	finished   bool
+	err        error
+	done       chan struct{}
+	cancel     context.CancelFunc
+	// gen counts Resets; a send only finishes the generation it was started in.
+	gen uint64
+}
+
+// NewNotification returns an undispatched notification for cfg, delivered by
+// send. The attempt timeout is cfg.Parameters["timeout"] (a duration string
+// or seconds), DefaultNotificationTimeout otherwise.
+func NewNotification[P NotifierConfig[P] | LLMProviderConfig | ChatRequest | LLMConfig | any](cfg NotifierConfig[P], send NotificationSender[P]) *Notification[P] {
+	if cfg.CreatedAt.IsZero() {
+		cfg.CreatedAt = time.Now()
+	}
+	cfg.Metadata = maps.Clone(cfg.Metadata)
+	cfg.Parameters = maps.Clone(cfg.Parameters)
+	return &Notification[P]{
+		ref:     NewGlobalRef("notification:" + cfg.Type),
+		cfg:     cfg,
+		send:    send,
+		timeout: notificationTimeout(cfg.Parameters),
+		attempt: 1,
+		done:    make(chan struct{}),
+	}
+}
+
+// Dispatch starts the delivery and returns a channel receiving its outcome
+// (nil on success) before closing. The delivery runs under ctx, bounded by
+// Timeout, and stops on Cancel.
+func (n *Notification[P]) Dispatch(ctx context.Context) <-chan error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.dispatched || n.finished {
+		return n.outcome()
+	}
+	n.dispatched = true
+	if n.send == nil {
+		n.finishLocked(ErrNoNotificationSender)
+		return n.outcome()
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, n.timeout)
+	n.cancel = cancel
+	gen := n.gen
+	go func() {
+		defer cancel()
+		err := n.send(sendCtx, n)
+		if err == nil && sendCtx.Err() != nil {
+			err = sendCtx.Err()
+		}
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		// A send cancelled and then Reset must not finish the new attempt.
+		if n.gen == gen && !n.finished {
+			n.finishLocked(err)
+		}
+	}()
+	return n.outcome()
+}
+
+// outcome returns a channel yielding the final error once the notification finishes.
+func (n *Notification[P]) outcome() <-chan error {
+	ch := make(chan error, 1)
+	done := n.done
+	go func() {
+		defer close(ch)
+		<-done
+		ch <- n.Error()
+	}()
+	return ch
+}
+
+// finishLocked records err as the outcome; n.mu must be held.
+func (n *Notification[P]) finishLocked(err error) {
+	n.finished = true
+	n.err = err
+	close(n.done)
+}
+
+// Done returns a channel closed once the notification is delivered, fails or is cancelled.
+func (n *Notification[P]) Done(context.Context) <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.done
+}
+
+// Cancel stops a pending or running delivery, which then fails with
+// ErrNotificationCanceled, and returns Done. It does nothing once finished.
+func (n *Notification[P]) Cancel(ctx context.Context) <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.finished {
+		if n.cancel != nil {
+			n.cancel()
+		}
+		n.finishLocked(ErrNotificationCanceled)
+	}
+	return n.done
+}
+
+// Error returns the outcome of a finished notification, nil while it runs.
+func (n *Notification[P]) Error() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.err
+}
+
+// DoneWithError yields the outcome once finished, or ctx's error if ctx ends first.
+func (n *Notification[P]) DoneWithError(ctx context.Context) <-chan error {
+	ch := make(chan error, 1)
+	done := n.Done(ctx)
+	go func() {
+		defer close(ch)
+		select {
+		case <-done:
+			ch <- n.Error()
+		case <-ctx.Done():
+			ch <- ctx.Err()
+		}
+	}()
+	return ch
+}
+
+// Reset returns a finished notification to its undispatched state so it can
+// be dispatched again under the same reference. It fails while a delivery runs;
+// a send still winding down after Cancel no longer affects the new state.
+func (n *Notification[P]) Reset(context.Context) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.dispatched && !n.finished {
+		return gl.Errorf("notification %s is still being delivered", n.ref.String())
+	}
+	n.dispatched, n.finished, n.err, n.cancel = false, false, nil, nil
+	n.done = make(chan struct{})
+	n.gen++
+	return nil
+}
+
+// Retry waits for this attempt to finish, then dispatches a new one with the
+// same config and sender and yields it. The channel closes without a value if
+// ctx ends first. This attempt is left untouched.
+func (n *Notification[P]) Retry(ctx context.Context) <-chan NotifierEvent[P] {
+	ch := make(chan NotifierEvent[P], 1)
+	done := n.Done(ctx)
+	go func() {
+		defer close(ch)
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return
+		}
+		next := NewNotification(n.cfg, n.send)
+		next.ref.Name = n.ref.Name
+		next.attempt = n.attempt + 1
+		next.Dispatch(ctx)
+		ch <- next
+	}()
+	return ch
+}
+
+// Wait blocks until the notification finishes and returns its outcome, or
+// ctx's error. It does not dispatch.
+func (n *Notification[P]) Wait(ctx context.Context) error {
+	return <-n.DoneWithError(ctx)
+}
+
+// WaitWithTimeout is Wait bounded by timeout.
+func (n *Notification[P]) WaitWithTimeout(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return n.Wait(ctx)
+}
+
+// WaitWithCondition is Wait followed by a Broadcast on cond, waking the
+// goroutines waiting on it for this notification.
+func (n *Notification[P]) WaitWithCondition(ctx context.Context, cond *sync.Cond) error {
+	err := n.Wait(ctx)
+	if cond != nil {
+		cond.L.Lock()
+		cond.Broadcast()
+		cond.L.Unlock()
+	}
+	return err
+}
+
+// Timeout returns the bound of one delivery attempt.
+func (n *Notification[P]) Timeout(context.Context) time.Duration { return n.timeout }
+
+// Attempt returns 1 for the original notification, 2 for its first retry, and so on.
+func (n *Notification[P]) Attempt() int { return n.attempt }
+
+// Config returns a copy of the notification's config.
+func (n *Notification[P]) Config() NotifierConfig[P] {
+	cfg := n.cfg
+	cfg.Metadata = maps.Clone(cfg.Metadata)
+	cfg.Parameters = maps.Clone(cfg.Parameters)
+	return cfg
+}
+
+func (n *Notification[P]) Ref() GlobalRef { return n.ref }
+func (n *Notification[P]) NType() (reflect.Type, string) {
+	t := reflect.TypeFor[P]()
+	return t, t.String()
+}
+
+func (n *Notification[P]) Type(context.Context) string      { return n.cfg.Type }
+func (n *Notification[P]) Recipient(context.Context) string { return n.cfg.Recipient }
+func (n *Notification[P]) Subject(context.Context) string   { return n.cfg.Subject }
+func (n *Notification[P]) Content(context.Context) string   { return n.cfg.Content }
+func (n *Notification[P]) Priority(context.Context) string  { return n.cfg.Priority }
+func (n *Notification[P]) Metadata(context.Context) map[string]any {
+	return n.cfg.Metadata
+}
+func (n *Notification[P]) CreatedAt(context.Context) time.Time { return n.cfg.CreatedAt }
+
+// notificationTimeout reads the "timeout" parameter as a duration string or seconds.
+func notificationTimeout(params map[string]any) time.Duration {
+	switch v := params["timeout"].(type) {
+	case time.Duration:
+		if v > 0 {
+			return v
+		}
+	case string:
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		if sec, err := strconv.ParseFloat(v, 64); err == nil && sec > 0 {
+			return time.Duration(sec * float64(time.Second))
+		}
+	case int:
+		if v > 0 {
+			return time.Duration(v) * time.Second
+		}
+	case float64:
+		if v > 0 {
+			return time.Duration(v * float64(time.Second))
+		}
+	}
+	return DefaultNotificationTimeout
+}
@@ -2,6 +2,7 @@ package types
 
 import (
 	"context"
+	"errors"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -14,10 +15,19 @@ import (
 )
 
 type ToolCall struct {
+	// ID correlates the call with its result (Message.ToolCallID).
+	ID   string `json:"id,omitempty"`
 	Name string `json:"name"`
 	Args any    `json:"args"` // geralmente map[string]any
 }
 
+// ToolSpec declares a tool the model may call. Parameters is a JSON Schema object.
+type ToolSpec struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
 // ChatRequest represents a chat completion request
 type ChatRequest struct {
 	Headers  map[string]string `json:"-"`
@@ -27,6 +37,25 @@ type ChatRequest struct {
 	Temp     float32           `json:"temperature"`
 	Stream   bool              `json:"stream"`
 	Meta     map[string]any    `json:"meta"`
+	// Sampling parameters. Zero values are unset and take LLMRequestDefaults
+	// when the request goes through the registry.
+	MaxTokens        int      `json:"max_tokens,omitempty"`
+	TopP             float32  `json:"top_p,omitempty"`
+	TopK             int      `json:"top_k,omitempty"`
+	Stop             []string `json:"stop,omitempty"`
+	Seed             *int64   `json:"seed,omitempty"`
+	FrequencyPenalty float32  `json:"frequency_penalty,omitempty"`
+	PresencePenalty  float32  `json:"presence_penalty,omitempty"`
+	// ReasoningEffort ("low", "medium", "high") or ReasoningBudget (tokens)
+	// enables and sizes the model's reasoning/thinking phase where supported.
+	ReasoningEffort string `json:"reasoning_effort,omitempty"`
+	ReasoningBudget int    `json:"reasoning_budget,omitempty"`
+	// Requires lets the registry route to a provider/model offering these capabilities.
+	Requires *ModelCapabilities `json:"requires,omitempty"`
+	// Timeouts overrides the provider's configured timeouts, field by field.
+	Timeouts *ChatTimeouts `json:"timeouts,omitempty"`
+	// Tools the model may call; calls come back as ChatChunk.ToolCall.
+	Tools []ToolSpec `json:"tools,omitempty"`
 }
 
 func (r ChatRequest) Validate() error {
@@ -62,6 +91,19 @@ func (r ChatRequest) Read(ctx context.Context) (ChatChunk, error) {
 			Temp:     r.Temp,
 			Stream:   r.Stream,
 			Meta:     r.Meta,
+			Requires: r.Requires,
+			Timeouts: r.Timeouts,
+			Tools:    r.Tools,
+
+			MaxTokens:        r.MaxTokens,
+			TopP:             r.TopP,
+			TopK:             r.TopK,
+			Stop:             r.Stop,
+			Seed:             r.Seed,
+			FrequencyPenalty: r.FrequencyPenalty,
+			PresencePenalty:  r.PresencePenalty,
+			ReasoningEffort:  r.ReasoningEffort,
+			ReasoningBudget:  r.ReasoningBudget,
 		},
 	)
 	if err != nil {
@@ -77,6 +119,11 @@ func (r ChatRequest) Read(ctx context.Context) (ChatChunk, error) {
 type Message struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
+	// ToolCalls are the calls requested by an assistant message.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID and Name identify the call a "tool" message answers.
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	Name       string `json:"name,omitempty"`
 }
 
 // Usage represents token usage and cost information
@@ -88,6 +135,8 @@ type Usage struct {
 	CostUSD    float64 `json:"cost_usd"`
 	Provider   string  `json:"provider"`
 	Model      string  `json:"model"`
+	// Reasoning counts the reasoning/thinking tokens, when the vendor reports them.
+	Reasoning int `json:"reasoning_tokens,omitempty"`
 }
 
 // ChatChunk represents a streaming response chunk
@@ -97,13 +146,30 @@ type ChatChunk struct {
 	Usage    *Usage    `json:"usage,omitempty"`
 	Error    string    `json:"error,omitempty"`
 	ToolCall *ToolCall `json:"toolCall,omitempty"`
+	// Reasoning carries reasoning/thinking deltas, kept apart from Content.
+	Reasoning string `json:"reasoning,omitempty"`
+	// Timeout is set on the final chunk when the request timed out; Error holds its message.
+	Timeout *TimeoutError `json:"timeout,omitempty"`
 }
 
-func (c ChatChunk) IsSuccess() bool   { return c.Error == "" }
-func (c ChatChunk) IsError() bool     { return c.Error != "" }
-func (c ChatChunk) IsDone() bool      { return c.Done }
-func (c ChatChunk) HasContent() bool  { return len(c.Content) > 0 }
-func (c ChatChunk) HasToolCall() bool { return c.ToolCall != nil }
+func (c ChatChunk) IsSuccess() bool    { return c.Error == "" }
+func (c ChatChunk) IsError() bool      { return c.Error != "" }
+func (c ChatChunk) IsDone() bool       { return c.Done }
+func (c ChatChunk) HasContent() bool   { return len(c.Content) > 0 }
+func (c ChatChunk) HasToolCall() bool  { return c.ToolCall != nil }
+func (c ChatChunk) HasReasoning() bool { return len(c.Reasoning) > 0 }
+func (c ChatChunk) IsTimeout() bool    { return c.Timeout != nil }
+
+// Err returns the chunk's error, typed as *TimeoutError for timeouts.
+func (c ChatChunk) Err() error {
+	if c.Timeout != nil {
+		return c.Timeout
+	}
+	if c.Error != "" {
+		return errors.New(c.Error)
+	}
+	return nil
+}
 
 type LLMRequestDefaults struct {
 	MaxTokens        int     `yaml:"max_tokens,omitempty" json:"max_tokens,omitempty" mapstructure:"max_tokens,omitempty"`
@@ -115,6 +181,10 @@ type LLMRequestDefaults struct {
 	TimeoutSec       int     `yaml:"timeout_sec,omitempty" json:"timeout_sec,omitempty" mapstructure:"timeout_sec,omitempty"`
 	TenantID         string  `yaml:"tenant_id,omitempty" json:"tenant_id,omitempty" mapstructure:"tenant_id,omitempty"`
 	UserID           string  `yaml:"user_id,omitempty" json:"user_id,omitempty" mapstructure:"user_id,omitempty"`
+	// Phase timeouts; unset ones fall back to TimeoutSec.
+	ConnectTimeoutSec    int `yaml:"connect_timeout_sec,omitempty" json:"connect_timeout_sec,omitempty" mapstructure:"connect_timeout_sec,omitempty"`
+	FirstTokenTimeoutSec int `yaml:"first_token_timeout_sec,omitempty" json:"first_token_timeout_sec,omitempty" mapstructure:"first_token_timeout_sec,omitempty"`
+	IdleTimeoutSec       int `yaml:"idle_timeout_sec,omitempty" json:"idle_timeout_sec,omitempty" mapstructure:"idle_timeout_sec,omitempty"`
 }
 
 type LLMTokenBucket struct {
@@ -170,6 +240,10 @@ type LLMProviderProductionConfig struct {
 	BaseDelayMS int     `yaml:"base_delay_ms,omitempty" json:"base_delay_ms,omitempty" mapstructure:"base_delay_ms,omitempty"`
 	MaxDelayMS  int     `yaml:"max_delay_ms,omitempty" json:"max_delay_ms,omitempty" mapstructure:"max_delay_ms,omitempty"`
 	Multiplier  float64 `yaml:"multiplier,omitempty" json:"multiplier,omitempty" mapstructure:"multiplier,omitempty"`
+	// Phase timeouts; unset ones fall back to TimeoutSec, then to the request defaults.
+	ConnectTimeoutSec    int `yaml:"connect_timeout_sec,omitempty" json:"connect_timeout_sec,omitempty" mapstructure:"connect_timeout_sec,omitempty"`
+	FirstTokenTimeoutSec int `yaml:"first_token_timeout_sec,omitempty" json:"first_token_timeout_sec,omitempty" mapstructure:"first_token_timeout_sec,omitempty"`
+	IdleTimeoutSec       int `yaml:"idle_timeout_sec,omitempty" json:"idle_timeout_sec,omitempty" mapstructure:"idle_timeout_sec,omitempty"`
 }
 
 type LLMSecurityConfig struct {
@@ -177,6 +251,9 @@ type LLMSecurityConfig struct {
 	AllowedOrigins []string `yaml:"allowed_origins,omitempty" json:"allowed_origins,omitempty" mapstructure:"allowed_origins,omitempty"`
 	JWTSecret      string   `yaml:"jwt_secret,omitempty" json:"jwt_secret,omitempty" mapstructure:"jwt_secret,omitempty"`
 	APIKeys        []string `yaml:"api_keys,omitempty" json:"api_keys,omitempty" mapstructure:"api_keys,omitempty"`
+	// TenantAPIKeys lists, by tenant, the API keys that act as that tenant.
+	// Keys in APIKeys act as the default tenant.
+	TenantAPIKeys map[string][]string `yaml:"tenant_api_keys,omitempty" json:"tenant_api_keys,omitempty" mapstructure:"tenant_api_keys,omitempty"`
 }
 
 type LLMMonitoringConfig struct {
@@ -212,6 +289,41 @@ type LLMConfig struct {
 	Version            string                                 `yaml:"version,omitempty" json:"version,omitempty" mapstructure:"version,omitempty"`
 	Authors            []string                               `yaml:"authors,omitempty" json:"authors,omitempty" mapstructure:"authors,omitempty"`
 	License            string                                 `yaml:"license,omitempty" json:"license,omitempty" mapstructure:"license,omitempty"`
+	// CapabilitiesFile points to a local YAML file overriding model capability descriptors.
+	CapabilitiesFile string `yaml:"capabilities_file,omitempty" json:"capabilities_file,omitempty" mapstructure:"capabilities_file,omitempty"`
+	// Tenants overlays the base config per tenant, keyed by tenant ID.
+	Tenants map[string]*LLMTenantConfig `yaml:"tenants,omitempty" json:"tenants,omitempty" mapstructure:"tenants,omitempty"`
+}
+
+// LLMTenantConfig overlays the base LLMConfig for one tenant.
+type LLMTenantConfig struct {
+	// DefaultProvider serves requests that name no provider.
+	DefaultProvider string `yaml:"default_provider,omitempty" json:"default_provider,omitempty" mapstructure:"default_provider,omitempty"`
+	// Providers overlays base providers by name.
+	Providers map[string]*LLMTenantProviderConfig `yaml:"providers,omitempty" json:"providers,omitempty" mapstructure:"providers,omitempty"`
+	// Restrict disables every base provider not listed in Providers.
+	Restrict bool `yaml:"restrict,omitempty" json:"restrict,omitempty" mapstructure:"restrict,omitempty"`
+	// RateLimit is a token bucket shared by all of the tenant's requests.
+	RateLimit *LLMTokenBucket `yaml:"rate_limit,omitempty" json:"rate_limit,omitempty" mapstructure:"rate_limit,omitempty"`
+	// MaxTokens caps ChatRequest.MaxTokens for the tenant.
+	MaxTokens int `yaml:"max_tokens,omitempty" json:"max_tokens,omitempty" mapstructure:"max_tokens,omitempty"`
+	// BudgetUSD caps the tenant's spend (Usage.CostUSD) for the life of the
+	// registry; once reached, requests are refused. Requests already in flight
+	// when it is reached still complete, so it can be overshot by their cost.
+	BudgetUSD float64 `yaml:"budget_usd,omitempty" json:"budget_usd,omitempty" mapstructure:"budget_usd,omitempty"`
+}
+
+// LLMTenantProviderConfig is a tenant's overlay for one base provider.
+type LLMTenantProviderConfig struct {
+	// Enabled set to false disables the provider for the tenant.
+	Enabled *bool `yaml:"enabled,omitempty" json:"enabled,omitempty" mapstructure:"enabled,omitempty"`
+	// KeyEnv is the tenant's own API key (env name, literal or keyring:/vault:/file:
+	// reference). When set the tenant is only ever served with this key.
+	KeyEnv string `yaml:"key_env,omitempty" json:"key_env,omitempty" mapstructure:"key_env,omitempty"`
+	// DefaultModel serves the tenant's requests that name no model.
+	DefaultModel string `yaml:"default_model,omitempty" json:"default_model,omitempty" mapstructure:"default_model,omitempty"`
+	// Models restricts the models the tenant may request; empty allows all.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty" mapstructure:"models,omitempty"`
 }
 
 func NewLLMConfig(path string, name string, version string, p map[string]*LLMProviderConfig) LLMConfig {
@@ -272,7 +384,6 @@ func NewLLMConfigDefault() LLMConfig {
 		Defaults: LLMRequestDefaults{
 			MaxTokens:        2048,
 			Temperature:      0.7,
-			TopP:             0.9,
 			FrequencyPenalty: 0.0,
 			PresencePenalty:  0.0,
 			Stream:           false,
@@ -498,6 +609,53 @@ type LLMProviderConfig struct {
 	BaseURL      string `yaml:"base_url,omitempty" json:"base_url,omitempty" mapstructure:"base_url,omitempty"`
 	KeyEnv       string `yaml:"key_env,omitempty" json:"key_env,omitempty" mapstructure:"key_env,omitempty"`
 	DefaultModel string `yaml:"default_model,omitempty" json:"default_model,omitempty" mapstructure:"default_model,omitempty"`
+	// KeyEnvs lists additional API key references (env names or literal keys) pooled with KeyEnv.
+	KeyEnvs []string `yaml:"key_envs,omitempty" json:"key_envs,omitempty" mapstructure:"key_envs,omitempty"`
+	// KeyStrategy selects how pooled keys are spread: "round_robin" (default) or "least_used".
+	KeyStrategy string `yaml:"key_strategy,omitempty" json:"key_strategy,omitempty" mapstructure:"key_strategy,omitempty"`
+	// ProviderType selects the adapter when it differs from the provider name (e.g. "mock").
+	ProviderType string `yaml:"type,omitempty" json:"type,omitempty" mapstructure:"type,omitempty"`
+	// Mock scripts the responses of a "mock" provider.
+	Mock *LLMMockConfig `yaml:"mock,omitempty" json:"mock,omitempty" mapstructure:"mock,omitempty"`
+}
+
+// LLMMockConfig scripts the built-in mock provider.
+type LLMMockConfig struct {
+	// Mode is "match" (default: first response whose regex matches the last
+	// message) or "sequence" (responses in order).
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty" mapstructure:"mode,omitempty"`
+	// Loop restarts a sequence once exhausted instead of repeating the last response.
+	Loop bool `yaml:"loop,omitempty" json:"loop,omitempty" mapstructure:"loop,omitempty"`
+	// ChunkSize splits Content into chunks of this many runes (whole content when zero).
+	ChunkSize int `yaml:"chunk_size,omitempty" json:"chunk_size,omitempty" mapstructure:"chunk_size,omitempty"`
+	// ChunkDelayMS is the pause between streamed chunks.
+	ChunkDelayMS int               `yaml:"chunk_delay_ms,omitempty" json:"chunk_delay_ms,omitempty" mapstructure:"chunk_delay_ms,omitempty"`
+	Responses    []LLMMockResponse `yaml:"responses,omitempty" json:"responses,omitempty" mapstructure:"responses,omitempty"`
+}
+
+// LLMMockResponse is one scripted answer of the mock provider.
+type LLMMockResponse struct {
+	// Match is a regular expression tested against the last message (match mode).
+	// An empty Match accepts any message.
+	Match   string   `yaml:"match,omitempty" json:"match,omitempty" mapstructure:"match,omitempty"`
+	Content string   `yaml:"content,omitempty" json:"content,omitempty" mapstructure:"content,omitempty"`
+	Chunks  []string `yaml:"chunks,omitempty" json:"chunks,omitempty" mapstructure:"chunks,omitempty"`
+	// Expand replaces $1 or ${name} in Content with Match's capture groups
+	// ($$ for a literal $). Content is sent verbatim otherwise.
+	Expand bool `yaml:"expand,omitempty" json:"expand,omitempty" mapstructure:"expand,omitempty"`
+	// Reasoning is streamed as reasoning deltas before the content.
+	Reasoning string `yaml:"reasoning,omitempty" json:"reasoning,omitempty" mapstructure:"reasoning,omitempty"`
+	// ToolCalls are emitted after the content chunks.
+	ToolCalls []ToolCall `yaml:"tool_calls,omitempty" json:"tool_calls,omitempty" mapstructure:"tool_calls,omitempty"`
+	// Error, when set, ends the stream with an error chunk after the content.
+	Error string `yaml:"error,omitempty" json:"error,omitempty" mapstructure:"error,omitempty"`
+	// FailRequest makes Chat itself return Error instead of streaming it.
+	FailRequest bool `yaml:"fail_request,omitempty" json:"fail_request,omitempty" mapstructure:"fail_request,omitempty"`
+	// DelayMS is the pause before the first chunk.
+	DelayMS          int     `yaml:"delay_ms,omitempty" json:"delay_ms,omitempty" mapstructure:"delay_ms,omitempty"`
+	PromptTokens     int     `yaml:"prompt_tokens,omitempty" json:"prompt_tokens,omitempty" mapstructure:"prompt_tokens,omitempty"`
+	CompletionTokens int     `yaml:"completion_tokens,omitempty" json:"completion_tokens,omitempty" mapstructure:"completion_tokens,omitempty"`
+	CostUSD          float64 `yaml:"cost_usd,omitempty" json:"cost_usd,omitempty" mapstructure:"cost_usd,omitempty"`
 }
 
 // NewLLMProviderConfigType exports concrete implementation of Provider interface for LLMProviderConfig to be used with caution
@@ -526,15 +684,23 @@ func NewLLMProviderConfigExt(name, baseurl, keyenv, defaultmodel string) Provide
 	return NewLLMProviderConfigType(name, baseurl, keyenv, defaultmodel)
 }
 
-func (pc *LLMProviderConfig) Name() string    { return pc.name }
-func (pc *LLMProviderConfig) Type() string    { return pc.typ }
+func (pc *LLMProviderConfig) Name() string { return pc.name }
+func (pc *LLMProviderConfig) Type() string {
+	if pc.ProviderType != "" {
+		return pc.ProviderType
+	}
+	return pc.typ
+}
 func (pc *LLMProviderConfig) URLBase() string { return pc.BaseURL }
 func (pc *LLMProviderConfig) Available() error {
 	if pc.BaseURL == "" || pc.KeyEnv == "" {
 		return gl.Errorf("provider '%s' is not properly configured", pc.typ)
 	}
-	if _, ok := os.LookupEnv(pc.KeyEnv); !ok {
-		return gl.Errorf("environment variable '%s' for provider '%s' is not set", pc.KeyEnv, pc.typ)
+	// Secret references ("keyring:", "vault:", "file:") are resolved by the registry, not the environment.
+	if !strings.Contains(pc.KeyEnv, ":") {
+		if _, ok := os.LookupEnv(pc.KeyEnv); !ok {
+			return gl.Errorf("environment variable '%s' for provider '%s' is not set", pc.KeyEnv, pc.typ)
+		}
 	}
 	if pc.DefaultModel == "" {
 		return gl.Errorf("provider '%s' does not have a default model configured", pc.typ)
@@ -0,0 +1,49 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ChatTimeouts bounds the phases of a streamed request. Zero fields are unset.
+type ChatTimeouts struct {
+	// Connect limits the wait for a connection to the provider.
+	Connect time.Duration `json:"connect,omitempty"`
+	// FirstToken limits the wait, from the start of the request, for the first
+	// content, reasoning or tool-call chunk.
+	FirstToken time.Duration `json:"first_token,omitempty"`
+	// Idle limits the gap between consecutive chunks once streaming started.
+	Idle time.Duration `json:"idle,omitempty"`
+}
+
+// IsZero reports whether no timeout is set.
+func (t ChatTimeouts) IsZero() bool { return t == ChatTimeouts{} }
+
+// TimeoutPhase names the phase of a request that timed out.
+type TimeoutPhase string
+
+const (
+	TimeoutConnect    TimeoutPhase = "connect"
+	TimeoutFirstToken TimeoutPhase = "first_token"
+	TimeoutIdle       TimeoutPhase = "idle"
+	// TimeoutDeadline is the caller's context deadline.
+	TimeoutDeadline TimeoutPhase = "deadline"
+)
+
+// ErrTimeout matches every *TimeoutError with errors.Is.
+var ErrTimeout = errors.New("llm request timed out")
+
+// TimeoutError is carried by the final ChatChunk of a request that timed out.
+type TimeoutError struct {
+	Phase    TimeoutPhase `json:"phase"`
+	AfterMs  int64        `json:"after_ms"`
+	Provider string       `json:"provider,omitempty"`
+	Model    string       `json:"model,omitempty"`
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("provider '%s' timed out (%s) after %dms", e.Provider, e.Phase, e.AfterMs)
+}
+
+func (e *TimeoutError) Is(target error) bool { return target == ErrTimeout }